@@ -0,0 +1,87 @@
+package journalentry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+)
+
+// EntriesFromZip reads the entries in the zip archive named by name, sorted by date.
+// Each Entry's Path is its name within the archive. Members that fail to parse are left out,
+// and their errors are joined into the returned error.
+func EntriesFromZip(name string) ([]*Entry, error) {
+	r, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return EntriesFromZipReader(&r.Reader)
+}
+
+// EntriesFromZipReader is like EntriesFromZip, but reads from an already opened archive, such as one held in memory.
+func EntriesFromZipReader(r *zip.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	var errs []error
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !IsEntry(path.Base(f.Name)) {
+			continue
+		}
+		p, err := readZipEntry(f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Name, err))
+			continue
+		}
+		entries = append(entries, p)
+	}
+	SortEntries(entries, ByDate)
+	return entries, errors.Join(errs...)
+}
+
+func readZipEntry(f *zip.File) (*Entry, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	p := &Entry{Path: f.Name, ModTime: f.Modified}
+	return p, p.parse(data)
+}
+
+// EntriesFromTar is like EntriesFromZip, but reads a tar archive from r.
+// Wrap r in a gzip.Reader to read a .tar.gz.
+func EntriesFromTar(r io.Reader) ([]*Entry, error) {
+	tr := tar.NewReader(r)
+	var entries []*Entry
+	var errs []error
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		if hdr.Typeflag != tar.TypeReg || !IsEntry(path.Base(hdr.Name)) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return entries, err
+		}
+		p := &Entry{Path: hdr.Name, ModTime: hdr.ModTime}
+		if err := p.parse(data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hdr.Name, err))
+			continue
+		}
+		entries = append(entries, p)
+	}
+	SortEntries(entries, ByDate)
+	return entries, errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package journalentry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// archiveFiles are the members of the test archives: two entries, a non-entry, and a broken entry.
+var archiveFiles = []struct{ name, contents string }{
+	{"journal/2020-01-03-Journal-Entry-for-Jan-3.md", "---\nhighmood: 5\n---\nsecond\n"},
+	{"journal/2020-01-02-Journal-Entry-for-Jan-2.md", "---\nhighmood: 4\n---\nfirst\n"},
+	{"journal/notes.txt", "not an entry\n"},
+	{"journal/2020-01-04-Journal-Entry-for-Jan-4.md", "---\nhighmood: [\n---\n"},
+}
+
+func checkArchiveEntries(t *testing.T, entries []*Entry, err error) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), "2020-01-04") {
+		t.Errorf("error = %v, want one for the broken member", err)
+	}
+	want := []string{"journal/2020-01-02-Journal-Entry-for-Jan-2.md", "journal/2020-01-03-Journal-Entry-for-Jan-3.md"}
+	if got := paths(entries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+	if entries[0].HighMood != 4 || string(entries[0].Body) != "first\n" {
+		t.Errorf("first entry has HighMood %d, Body %q", entries[0].HighMood, entries[0].Body)
+	}
+}
+
+func TestEntriesFromZipReader(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(f.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := EntriesFromZipReader(r)
+	checkArchiveEntries(t, entries, err)
+}
+
+func TestEntriesFromTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0666, Size: int64(len(f.contents))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := EntriesFromTar(&buf)
+	checkArchiveEntries(t, entries, err)
+}
@@ -0,0 +1,150 @@
+package journalentry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// IssueKind classifies a problem found by Audit.
+type IssueKind int
+
+const (
+	// MalformedName is a file named like an Entry whose date can't be parsed.
+	MalformedName IssueKind = iota
+	// DateMismatch is a filename whose textual month and day disagree with its numeric date.
+	DateMismatch
+	// FutureDate is an Entry dated after today.
+	FutureDate
+	// LoadFailure is an Entry that can't be read or parsed.
+	LoadFailure
+	// MoodOutOfRange is an Entry with a rating outside 1-5.
+	MoodOutOfRange
+	// EmptyEntry is an Entry with no text in its body.
+	EmptyEntry
+)
+
+func (k IssueKind) String() string {
+	switch k {
+	case MalformedName:
+		return "malformed name"
+	case DateMismatch:
+		return "date mismatch"
+	case FutureDate:
+		return "future date"
+	case LoadFailure:
+		return "load failure"
+	case MoodOutOfRange:
+		return "mood out of range"
+	case EmptyEntry:
+		return "empty entry"
+	}
+	return fmt.Sprintf("IssueKind(%d)", int(k))
+}
+
+// AuditIssue is a problem with a single file found by Audit.
+type AuditIssue struct {
+	Path    string
+	Kind    IssueKind
+	Message string
+}
+
+// looseEntryRegex matches names that were probably meant to be entries.
+const looseEntryRegex = `(?i)journal-entry`
+
+// Audit checks every file in dir that looks like an Entry and reports the problems found. An Entry's sidecar file is
+// checked as part of it, not as a file of its own.
+// A problem with one file doesn't stop the others from being checked; the error is only for failing to read dir.
+func Audit(dir string) ([]AuditIssue, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var issues []AuditIssue
+	report := func(path string, kind IssueKind, format string, a ...interface{}) {
+		issues = append(issues, AuditIssue{Path: path, Kind: kind, Message: fmt.Sprintf(format, a...)})
+	}
+	loose := regexp.MustCompile(looseEntryRegex)
+	today := day(time.Now())
+	sidecars := make(map[string]bool)
+	for _, f := range files {
+		if !f.IsDir() && IsEntry(f.Name()) {
+			sidecars[sidecarPath(f.Name())] = true
+		}
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !loose.MatchString(name) || sidecars[name] {
+			continue
+		}
+		p := &Entry{Path: filepath.Join(dir, name)}
+		date, err := p.Date()
+		if !IsEntry(name) || err != nil {
+			report(p.Path, MalformedName, "name doesn't match %q", entryFormat)
+			continue
+		}
+		if !datesAgree(baseName(name)) {
+			report(p.Path, DateMismatch, "numeric and textual dates in name disagree")
+		}
+		if date.After(today) {
+			report(p.Path, FutureDate, "dated %s, after today", date.Format("2006-01-02"))
+		}
+		if _, err := p.Load(); err != nil {
+			report(p.Path, LoadFailure, "%v", err)
+			continue
+		}
+		for _, r := range p.ratings() {
+			if r.value > 5 {
+				report(p.Path, MoodOutOfRange, "%s is %d, want 1-5", r.field, r.value)
+			}
+		}
+		if len(bytes.TrimSpace(p.Body)) == 0 {
+			report(p.Path, EmptyEntry, "body is empty")
+		}
+	}
+	return issues, nil
+}
+
+// textualDateRegex captures the textual month and day of an Entry file name, as in "...-for-Jan-2.md".
+const textualDateRegex = `-for-(\D{3})-(\d{1,2})\.md$`
+
+// datesAgree reports whether the numeric date at the start of the Entry file name matches its textual month and day,
+// ignoring case and zero padding, so "...-for-jan-03.md" agrees with "2020-01-03". A name without a textual date agrees.
+func datesAgree(name string) bool {
+	m := regexp.MustCompile(textualDateRegex).FindStringSubmatch(name)
+	if m == nil {
+		return true
+	}
+	numeric, err := time.Parse("2006-01-02", name[:len("2006-01-02")])
+	if err != nil {
+		return false
+	}
+	month, err := time.Parse("Jan", m[1])
+	if err != nil {
+		return false
+	}
+	d, err := strconv.Atoi(m[2])
+	return err == nil && month.Month() == numeric.Month() && d == numeric.Day()
+}
+
+// Validate checks p on its own, as Audit does for each Entry in a directory: that a date can be parsed from p.Path
+// and that every rating is within 1-5 or unset. All the problems found are joined into the returned error.
+func (p *Entry) Validate() error {
+	var errs []error
+	if _, err := p.Date(); err != nil {
+		errs = append(errs, fmt.Errorf("date: %w", err))
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, r := range p.ratings() {
+		if r.value > 5 {
+			errs = append(errs, fmt.Errorf("%s is %d, want 1-5", r.field, r.value))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package journalentry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"2020-01-40-Journal-Entry.md":                   "body\n",
+		"2020-01-05-Journal-Entry-for-Feb-5.md":         "body\n",
+		time.Now().AddDate(1, 0, 0).Format(entryFormat): "body\n",
+		"2020-01-06-Journal-Entry-for-Jan-6.md":         "---\nhighmood: [\n---\nbody\n",
+		"2020-01-07-Journal-Entry-for-Jan-7.md":         "---\nhighmood: 9\n---\nbody\n",
+		"2020-01-08-Journal-Entry-for-Jan-8.md":         "---\nhighmood: 3\n---\n\n",
+		"2020-01-09-Journal-Entry-for-Jan-9.md":         "---\nhighmood: 3\n---\nfine\n",
+		"2020-01-10-Journal-Entry-for-jan-10.md":        "---\nhighmood: 3\n---\nlower case\n",
+		"2020-01-03-Journal-Entry-for-JAN-03.md":        "---\nhighmood: 3\n---\nzero padded\n",
+		"2020-01-11-Journal-Entry-for-Jan-12.md":        "body\n",
+		"2020-01-12-Journal-Entry-for-Jan-12.md":        "prose in a sidecar Entry\n",
+		"2020-01-12-Journal-Entry-for-Jan-12.yml":       "highmood: 3\n",
+		"2020-01-13-Journal-Entry-for-Jan-13.yml":       "highmood: 3\n",
+		"notes.txt": "not an entry\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	issues, err := Audit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]IssueKind{
+		"2020-01-40-Journal-Entry.md":                   MalformedName,
+		"2020-01-05-Journal-Entry-for-Feb-5.md":         DateMismatch,
+		"2020-01-11-Journal-Entry-for-Jan-12.md":        DateMismatch,
+		"2020-01-13-Journal-Entry-for-Jan-13.yml":       MalformedName,
+		time.Now().AddDate(1, 0, 0).Format(entryFormat): FutureDate,
+		"2020-01-06-Journal-Entry-for-Jan-6.md":         LoadFailure,
+		"2020-01-07-Journal-Entry-for-Jan-7.md":         MoodOutOfRange,
+		"2020-01-08-Journal-Entry-for-Jan-8.md":         EmptyEntry,
+	}
+	got := make(map[string]IssueKind)
+	for _, issue := range issues {
+		if issue.Message == "" {
+			t.Errorf("issue %v has no message", issue)
+		}
+		got[filepath.Base(issue.Path)] = issue.Kind
+	}
+	if len(issues) != len(want) {
+		t.Errorf("Audit found %d issues, want %d: %v", len(issues), len(want), issues)
+	}
+	for name, kind := range want {
+		if k, ok := got[name]; !ok || k != kind {
+			t.Errorf("issue for %s = %v (found %v), want %v", name, k, ok, kind)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (&Entry{Path: entryPath("journal", testDate), HighMood: 5, LowMood: 1}).Validate(); err != nil {
+		t.Errorf("Validate of a valid Entry = %v", err)
+	}
+	err := (&Entry{Path: "notes.md", HighMood: 6, AverageMood: 9}).Validate()
+	if err == nil {
+		t.Fatal("Validate of an Entry with a bad name and moods succeeded")
+	}
+	want := []string{"date: ", "HighMood is 6", "AverageMood is 9"}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != len(want) {
+		t.Errorf("Validate = %q, want %d joined problems", err, len(want))
+	}
+	for _, problem := range want {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Validate = %q, missing %q", err, problem)
+		}
+	}
+}
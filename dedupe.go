@@ -0,0 +1,160 @@
+package journalentry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MergeStrategy decides which value a merged Entry keeps for a field set differently in the entries being merged.
+type MergeStrategy int
+
+const (
+	// KeepFirst keeps the value from the first entry that sets the field, in order of their same-day suffixes.
+	KeepFirst MergeStrategy = iota
+	// KeepLast keeps the value from the last entry that sets the field.
+	KeepLast
+)
+
+// DedupeSameDay merges each set of entries in dir sharing a date into the first of them, in order of their same-day suffixes,
+// deletes the others, and returns how many sets were merged.
+// The bodies are joined in order, separated by a blank line. Seconds are added up; the entry is Pinned or Private
+// if any of them is; Attachments and Tags are combined; other fields set in more than one entry are resolved by strategy.
+// A set containing a file that fails to load is left as it was, and its error is joined into the returned error.
+func DedupeSameDay(dir string, strategy MergeStrategy) (int, error) {
+	paths, err := entryPaths(dir)
+	if err != nil {
+		return 0, err
+	}
+	groups := make(map[time.Time][]string)
+	var dates []time.Time
+	for _, path := range paths {
+		date, err := ParseDate(path)
+		if err != nil {
+			continue
+		}
+		if groups[date] == nil {
+			dates = append(dates, date)
+		}
+		groups[date] = append(groups[date], path)
+	}
+	merged := 0
+	var errs []error
+	for _, date := range dates {
+		group := groups[date]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return suffixOf(group[i]) < suffixOf(group[j]) })
+		if err := mergeFiles(group, strategy); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		merged++
+	}
+	return merged, errors.Join(errs...)
+}
+
+// mergeFiles merges the entries at paths into the first and removes the rest.
+func mergeFiles(paths []string, strategy MergeStrategy) error {
+	entries := make([]*Entry, len(paths))
+	for i, path := range paths {
+		entries[i] = &Entry{Path: path, AtomicSave: true}
+		if _, err := entries[i].Load(); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	dst := entries[0]
+	for _, src := range entries[1:] {
+		dst.merge(src, strategy)
+	}
+	if err := dst.Save(); err != nil {
+		return fmt.Errorf("%s: %w", dst.Path, err)
+	}
+	var errs []error
+	for _, path := range paths[1:] {
+		if err := removeEntry(OS, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// merge combines src into p as DedupeSameDay describes.
+func (p *Entry) merge(src *Entry, strategy MergeStrategy) {
+	pick := func(dst, src uint8) uint8 {
+		if src == 0 || (strategy == KeepFirst && dst != 0) {
+			return dst
+		}
+		return src
+	}
+	p.LowMood = pick(p.LowMood, src.LowMood)
+	p.HighMood = pick(p.HighMood, src.HighMood)
+	p.AverageMood = pick(p.AverageMood, src.AverageMood)
+	p.Energy = pick(p.Energy, src.Energy)
+	p.SleepQuality = pick(p.SleepQuality, src.SleepQuality)
+	if src.Headline != "" && (strategy == KeepLast || p.Headline == "") {
+		p.Headline = src.Headline
+	}
+	if src.WordGoal != 0 && (strategy == KeepLast || p.WordGoal == 0) {
+		p.WordGoal = src.WordGoal
+	}
+	p.Seconds = uint32(math.Min(float64(p.Seconds)+float64(src.Seconds), math.MaxUint32))
+	p.Pinned = p.Pinned || src.Pinned
+	p.Private = p.Private || src.Private
+	p.Attachments = union(p.Attachments, src.Attachments)
+	p.Tags = union(p.Tags, src.Tags)
+	for _, item := range src.extra {
+		i := 0
+		for i < len(p.extra) && p.extra[i].Key != item.Key {
+			i++
+		}
+		if i == len(p.extra) {
+			p.extra = append(p.extra, item)
+		} else if strategy == KeepLast {
+			p.extra[i] = item
+		}
+	}
+	body := bytes.TrimRight(p.Body, "\n")
+	if other := bytes.TrimRight(src.Body, "\n"); len(other) > 0 {
+		if len(body) > 0 {
+			body = append(body, "\n\n"...)
+		}
+		body = append(body, other...)
+	}
+	if len(body) > 0 {
+		body = append(body, '\n')
+	}
+	p.Body = body
+}
+
+// union returns the strings in a followed by those in b that aren't in a.
+func union(a, b []string) []string {
+	for _, s := range b {
+		found := false
+		for _, t := range a {
+			found = found || s == t
+		}
+		if !found {
+			a = append(a, s)
+		}
+	}
+	return a
+}
+
+// suffixOf returns the same-day suffix of the Entry file at path, or 0 if it has none.
+func suffixOf(path string) int {
+	name := strings.TrimSuffix(filepath.Base(path), ".gz")
+	if name == baseName(name) {
+		return 0
+	}
+	n, _ := strconv.Atoi(regexp.MustCompile(`-(\d+)\.md$`).FindStringSubmatch(name)[1])
+	return n
+}
@@ -0,0 +1,62 @@
+package journalentry
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDedupeSameDay(t *testing.T) {
+	for _, tt := range []struct {
+		strategy MergeStrategy
+		high     uint8
+	}{
+		{KeepFirst, 4},
+		{KeepLast, 5},
+	} {
+		dir := t.TempDir()
+		first := writeEntry(t, dir, testDate, "---\nseconds: 60\nhighmood: 4\ntags: [work]\n---\nmorning\n")
+		second := withSuffix(first, 2)
+		if err := os.WriteFile(second, []byte("---\nseconds: 30\nhighmood: 5\nlowmood: 2\ntags: [work, gym]\npinned: true\n---\nevening\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+		other := writeEntry(t, dir, testDate.AddDate(0, 0, 1), "---\n---\nalone\n")
+
+		n, err := DedupeSameDay(dir, tt.strategy)
+		if err != nil || n != 1 {
+			t.Fatalf("DedupeSameDay(%v) = %d, %v, want 1", tt.strategy, n, err)
+		}
+		if _, err := os.Stat(second); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after merging: %v", second, err)
+		}
+		p := &Entry{Path: first}
+		if _, err := p.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if p.HighMood != tt.high || p.LowMood != 2 || p.Seconds != 90 || !p.Pinned {
+			t.Errorf("merged with %v: HighMood %d, LowMood %d, Seconds %d, Pinned %t, want %d, 2, 90, true",
+				tt.strategy, p.HighMood, p.LowMood, p.Seconds, p.Pinned, tt.high)
+		}
+		if !reflect.DeepEqual(p.Tags, []string{"work", "gym"}) || string(p.Body) != "morning\n\nevening\n" {
+			t.Errorf("merged Tags %v, Body %q", p.Tags, p.Body)
+		}
+		if got := readString(t, other); got != "---\n---\nalone\n" {
+			t.Errorf("entry alone on its day = %q, want it untouched", got)
+		}
+	}
+}
+
+func TestDedupeSameDayLoadError(t *testing.T) {
+	dir := t.TempDir()
+	first := writeEntry(t, dir, testDate, "---\nhighmood: [\n---\nbroken\n")
+	second := withSuffix(first, 2)
+	if err := os.WriteFile(second, []byte("second\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := DedupeSameDay(dir, KeepFirst); err == nil || n != 0 {
+		t.Errorf("DedupeSameDay with a broken entry = %d, %v, want 0 and an error", n, err)
+	}
+	if got := readString(t, second); got != "second\n" {
+		t.Errorf("second entry = %q, want it left as it was", got)
+	}
+}
@@ -0,0 +1,235 @@
+package journalentry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// diffContext is the number of unchanged lines shown around each change by Diff.
+const diffContext = 3
+
+// Diff returns a unified diff from the file named by p.Path to p as Save would write it,
+// or an empty string if they are the same. A missing file is treated as empty.
+// If p.Sidecar is set, the diff also covers its sidecar file.
+func (p *Entry) Diff() (string, error) {
+	if !p.Sidecar {
+		rendered, err := p.Render()
+		if err != nil {
+			return "", err
+		}
+		return p.diffFile(p.Path, rendered)
+	}
+	p.mu.RLock()
+	meta, err := yaml.Marshal(p)
+	body := append([]byte(nil), p.Body...)
+	p.mu.RUnlock()
+	if err != nil {
+		return "", err
+	}
+	metaDiff, err := p.diffFile(p.SidecarPath(), meta)
+	if err != nil {
+		return "", err
+	}
+	bodyDiff, err := p.diffFile(p.Path, body)
+	return metaDiff + bodyDiff, err
+}
+
+// diffFile returns a unified diff from the file named by path in p's FS to rendered,
+// or an empty string if they are the same. A missing file is treated as empty.
+func (p *Entry) diffFile(path string, rendered []byte) (string, error) {
+	disk, err := readFile(p.fs(), path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if bytes.HasPrefix(disk, gzipMagic) {
+		if disk, err = gunzip(disk, 0); err != nil {
+			return "", err
+		}
+	}
+	disk = bytes.ReplaceAll(disk, []byte("\r\n"), []byte("\n"))
+	if bytes.Equal(disk, rendered) {
+		return "", nil
+	}
+	return unifiedDiff(path, splitLines(disk), splitLines(rendered)), nil
+}
+
+// RoundTripError describes how an entry file differs from what Save would write after loading it.
+type RoundTripError struct {
+	Path string
+	Diff string // a unified diff from the file to the re-rendered entry
+}
+
+func (e *RoundTripError) Error() string {
+	return fmt.Sprintf("%s doesn't survive a load and save unchanged:\n%s", e.Path, e.Diff)
+}
+
+// VerifyRoundTrip loads the file named by p.Path and reports whether saving it would reproduce the file byte for byte,
+// using p's options such as NestedMoods and LineEnding. On a mismatch, the error is a *RoundTripError with a diff.
+// An entry with a sidecar file is checked against both files. p itself is not modified.
+func (p *Entry) VerifyRoundTrip() (bool, error) {
+	raw, err := readFile(p.fs(), p.Path)
+	if err != nil {
+		return false, err
+	}
+	meta, err := p.readSidecar()
+	if err != nil {
+		return false, err
+	}
+	p.mu.RLock()
+	c := p.clone()
+	p.mu.RUnlock()
+	if meta == nil {
+		if err := c.parse(raw); err != nil {
+			return false, err
+		}
+		rendered, err := c.render()
+		if err != nil {
+			return false, err
+		}
+		return c.verifyFile(p.Path, raw, rendered)
+	}
+	if err := c.parseSidecar(meta, raw); err != nil {
+		return false, err
+	}
+	rendered, err := yaml.Marshal(c)
+	if err != nil {
+		return false, err
+	}
+	if ok, err := c.verifyFile(c.SidecarPath(), meta, rendered); !ok {
+		return false, err
+	}
+	return c.verifyFile(p.Path, raw, c.Body)
+}
+
+// verifyFile reports whether raw, the contents of the file named by path, is what Save would write given rendered.
+// On a mismatch, the error is a *RoundTripError with a diff.
+func (p *Entry) verifyFile(path string, raw, rendered []byte) (bool, error) {
+	rendered = p.withLineEnding(rendered)
+	original := raw
+	if bytes.HasPrefix(raw, gzipMagic) {
+		var err error
+		if original, err = gunzip(raw, 0); err != nil {
+			return false, err
+		}
+	}
+	if bytes.Equal(original, rendered) {
+		return true, nil
+	}
+	return false, &RoundTripError{Path: path, Diff: unifiedDiff(path, splitLines(original), splitLines(rendered))}
+}
+
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is one line of an edit script: ' ' for a kept line, '-' for a removed one, '+' for an added one.
+type diffOp struct {
+	kind       byte
+	line       string
+	aPos, bPos int // the line's index in a and b, or the index it would have
+}
+
+// editScript returns the changes that turn a into b, found from their longest common subsequence.
+func editScript(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// unifiedDiff formats the changes from a to b, both the contents of name, as a unified diff.
+func unifiedDiff(name string, a, b []string) string {
+	ops := editScript(a, b)
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s (unsaved)\n", name, name)
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// Extend the hunk until the next change is more than two contexts away.
+		end := start
+		for k := start; k < len(ops) && k-end <= 2*diffContext; k++ {
+			if ops[k].kind != ' ' {
+				end = k + 1
+			}
+		}
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		to := end + diffContext
+		if to > len(ops) {
+			to = len(ops)
+		}
+		aLen, bLen := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(ops[from].aPos, aLen), hunkRange(ops[from].bPos, bLen))
+		for _, op := range ops[from:to] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return buf.String()
+}
+
+// hunkRange formats the start and length of a hunk's lines, numbering from 1 as unified diffs do.
+func hunkRange(pos, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", pos)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", pos+1)
+	}
+	return fmt.Sprintf("%d,%d", pos+1, length)
+}
@@ -0,0 +1,81 @@
+package journalentry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiffUnchanged(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "---\nhighmood: 4\n---\nbody\n")}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if diff, err := p.Diff(); err != nil || diff != "" {
+		t.Errorf("Diff of an unchanged Entry = %q, %v", diff, err)
+	}
+}
+
+func TestDiffModifiedBody(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate), Body: []byte("one\ntwo\nthree\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetBody([]byte("one\n2\nthree\n"))
+	diff, err := p.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"--- " + p.Path + "\n", "+++ " + p.Path + " (unsaved)\n", "@@ ", "-two\n", "+2\n", " one\n", " three\n"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Diff = %q, missing %q", diff, want)
+		}
+	}
+}
+
+func TestDiffMissingFile(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate), Body: []byte("new\n")}
+	diff, err := p.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+new\n") || strings.Contains(diff, "\n-") {
+		t.Errorf("Diff against a missing file = %q, want only additions", diff)
+	}
+}
+
+func TestVerifyRoundTripStable(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate), HighMood: 4, LowMood: 2, Body: []byte("body\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	before := readString(t, p.Path)
+	if ok, err := (&Entry{Path: p.Path}).VerifyRoundTrip(); !ok || err != nil {
+		t.Errorf("VerifyRoundTrip of a saved Entry = %t, %v", ok, err)
+	}
+	if after := readString(t, p.Path); after != before {
+		t.Errorf("VerifyRoundTrip changed the file from %q to %q", before, after)
+	}
+}
+
+func TestVerifyRoundTripReordered(t *testing.T) {
+	path := writeEntry(t, t.TempDir(), testDate, "---\nseconds: 0\nhighmood: 4\nlowmood: 2\naveragemood: 0\n---\nbody\n")
+	p := &Entry{Path: path}
+	ok, err := p.VerifyRoundTrip()
+	if ok {
+		t.Fatal("VerifyRoundTrip of reordered keys = true")
+	}
+	var rtErr *RoundTripError
+	if !errors.As(err, &rtErr) {
+		t.Fatalf("VerifyRoundTrip error = %v, want a *RoundTripError", err)
+	}
+	if rtErr.Path != path || !strings.Contains(rtErr.Diff, "-highmood: 4\n") || !strings.Contains(rtErr.Diff, "+highmood: 4\n") {
+		t.Errorf("RoundTripError = %+v, want a diff moving highmood", rtErr)
+	}
+	if p.HighMood != 0 || p.Body != nil {
+		t.Errorf("VerifyRoundTrip modified p: %+v", p)
+	}
+}
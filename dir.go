@@ -0,0 +1,485 @@
+package journalentry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entries loads every Entry in dir, sorted by date.
+// Files that fail to load are left out, and their errors are joined into the returned error.
+func Entries(dir string) ([]*Entry, error) {
+	return EntriesConcurrent(dir, 1)
+}
+
+// AllEntries returns an iterator over the entries in dir in date order, loading each only when it is reached.
+// It has the shape of an iter.Seq2, so callers can range over it. A failure to read dir or to load a file is
+// yielded as an error; iteration stops as soon as yield returns false.
+func AllEntries(dir string) func(yield func(*Entry, error) bool) {
+	return func(yield func(*Entry, error) bool) {
+		paths, err := entryPaths(dir)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, path := range paths {
+			p := &Entry{Path: path}
+			if _, err := p.Load(); err != nil {
+				if !yield(nil, fmt.Errorf("%s: %w", path, err)) {
+					return
+				}
+				continue
+			}
+			if !yield(p, nil) {
+				return
+			}
+		}
+	}
+}
+
+// EntriesConcurrent is like Entries, but loads up to workers files at a time.
+func EntriesConcurrent(dir string, workers int) ([]*Entry, error) {
+	return entriesConcurrent(nil, dir, workers)
+}
+
+// EntriesFS is like Entries, but reads dir in fsys. The returned entries keep using fsys.
+func EntriesFS(fsys FS, dir string) ([]*Entry, error) {
+	return entriesConcurrent(fsys, dir, 1)
+}
+
+func entriesConcurrent(fsys FS, dir string, workers int) ([]*Entry, error) {
+	paths, err := entryPathsFS(fsOrOS(fsys), dir)
+	if err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	loaded := make([]*Entry, len(paths))
+	errs := make([]error, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				p := &Entry{Path: paths[i], FS: fsys}
+				if _, err := p.Load(); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", paths[i], err)
+					continue
+				}
+				loaded[i] = p
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var entries []*Entry
+	for _, p := range loaded {
+		if p != nil {
+			entries = append(entries, p)
+		}
+	}
+	return entries, errors.Join(errs...)
+}
+
+// entryPaths returns the paths of the Entry files in dir, sorted by date.
+// Subdirectories are skipped even if they are named like entries; symbolic links are kept.
+func entryPaths(dir string) ([]string, error) {
+	return entryPathsFS(OS, dir)
+}
+
+// entryPathsFS is entryPaths for dir in fsys.
+func entryPathsFS(fsys FS, dir string) ([]string, error) {
+	files, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, f := range files {
+		if !f.IsDir() && IsEntry(f.Name()) {
+			paths = append(paths, filepath.Join(dir, f.Name()))
+		}
+	}
+	sortPaths(paths)
+	return paths, nil
+}
+
+// sortPaths sorts the Entry file paths by date and then by same-day suffix, so that a day's original file
+// comes before its "-2" and "-10" files. Paths whose dates can't be parsed sort after the rest, by path.
+func sortPaths(paths []string) {
+	keys := make(map[string]pathKey, len(paths))
+	for _, path := range paths {
+		keys[path] = keyOf(path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return keys[paths[i]].less(keys[paths[j]])
+	})
+}
+
+// pathKey is what sortPaths orders an Entry file path by.
+type pathKey struct {
+	path   string
+	date   time.Time
+	ok     bool // whether date was parsed
+	suffix int
+}
+
+func keyOf(path string) pathKey {
+	date, err := ParseDate(path)
+	return pathKey{path: path, date: date, ok: err == nil, suffix: suffixOf(path)}
+}
+
+func (a pathKey) less(b pathKey) bool {
+	switch {
+	case a.ok != b.ok:
+		return a.ok
+	case a.ok && !a.date.Equal(b.date):
+		return a.date.Before(b.date)
+	case a.ok && a.suffix != b.suffix:
+		return a.suffix < b.suffix
+	}
+	return a.path < b.path
+}
+
+// entryDates returns the distinct dates of the Entry files in dir, in ascending order.
+// Files whose dates can't be parsed are skipped.
+func entryDates(dir string) ([]time.Time, error) {
+	return entryDatesFS(OS, dir)
+}
+
+// entryDatesFS is entryDates for dir in fsys.
+func entryDatesFS(fsys FS, dir string) ([]time.Time, error) {
+	paths, err := entryPathsFS(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var dates []time.Time
+	for _, path := range paths {
+		date, err := (&Entry{Path: path}).Date()
+		if err != nil {
+			continue
+		}
+		if len(dates) > 0 && date.Equal(dates[len(dates)-1]) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+// loadWhere loads the Entry files in dir whose filename dates satisfy match, sorted by date.
+// As with Entries, files that fail to load are left out and their errors joined.
+func loadWhere(dir string, match func(date time.Time) bool) ([]*Entry, error) {
+	paths, err := entryPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	var errs []error
+	for _, path := range paths {
+		p := &Entry{Path: path}
+		date, err := p.Date()
+		if err != nil || !match(date) {
+			continue
+		}
+		if _, err := p.Load(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		entries = append(entries, p)
+	}
+	return entries, errors.Join(errs...)
+}
+
+// day returns midnight UTC of t's calendar date, matching the dates parsed from filenames.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// FutureEntries returns the entries in dir dated after the calendar day of asOf, such as those created under clock skew.
+func FutureEntries(dir string, asOf time.Time) ([]*Entry, error) {
+	today := day(asOf)
+	return loadWhere(dir, func(date time.Time) bool { return date.After(today) })
+}
+
+// EntriesByDay loads the entries in dir for the given month, keyed by day of the month.
+// If several files share a day, the latest is kept: the one with the highest same-day suffix.
+func EntriesByDay(dir string, year int, month time.Month) (map[int]*Entry, error) {
+	entries, err := loadWhere(dir, func(date time.Time) bool {
+		return date.Year() == year && date.Month() == month
+	})
+	days := make(map[int]*Entry, len(entries))
+	for _, p := range entries {
+		date, _ := p.Date()
+		if q, ok := days[date.Day()]; !ok || suffixOf(p.Path) > suffixOf(q.Path) {
+			days[date.Day()] = p
+		}
+	}
+	return days, err
+}
+
+// HeatmapData returns the number of words written on each day of year in dir, keyed by the date at midnight UTC,
+// for a calendar heatmap. The words of several files on one day are added up. Days without an entry are absent,
+// so a day whose entries are empty is present with 0.
+// As with Entries, files that fail to load are left out and their errors returned.
+func HeatmapData(dir string, year int) (map[time.Time]int, error) {
+	entries, err := loadWhere(dir, func(date time.Time) bool {
+		return date.Year() == year
+	})
+	days := make(map[time.Time]int, len(entries))
+	for _, p := range entries {
+		date, _ := p.Date()
+		days[date] += p.WordCount()
+	}
+	return days, err
+}
+
+// Pinned returns the pinned entries in dir, sorted by date.
+func Pinned(dir string) ([]*Entry, error) {
+	entries, err := Entries(dir)
+	var pinned []*Entry
+	for _, p := range entries {
+		if p.Pinned {
+			pinned = append(pinned, p)
+		}
+	}
+	return pinned, err
+}
+
+// OnThisDay loads the entries in dir from any year whose month and day match ref's, sorted by year.
+func OnThisDay(dir string, ref time.Time) ([]*Entry, error) {
+	_, month, dayOfMonth := ref.Date()
+	return loadWhere(dir, func(date time.Time) bool {
+		return date.Month() == month && date.Day() == dayOfMonth
+	})
+}
+
+// MigrateAll loads each Entry in dir, applies transform, and atomically saves the result.
+// A file that fails to load, transform, or save is left as it was, and its error is joined into the returned error.
+func MigrateAll(dir string, transform func(*Entry) error) error {
+	paths, err := entryPaths(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, path := range paths {
+		p := &Entry{Path: path, AtomicSave: true}
+		if _, err := p.Load(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if err := transform(p); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if err := p.Save(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TotalWords returns the number of words in all the entries in dir, loading one at a time.
+// Files that fail to load are skipped, and their errors are joined into the returned error alongside the total.
+func TotalWords(dir string) (int, error) {
+	total := 0
+	var errs []error
+	AllEntries(dir)(func(p *Entry, err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+			return true
+		}
+		total += p.WordCount()
+		return true
+	})
+	return total, errors.Join(errs...)
+}
+
+// freePath returns path if no file exists there, or else the first suffixed variant of path that is free.
+func freePath(path string) (string, error) {
+	for candidate, n := path, 2; ; n++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+		candidate = withSuffix(path, n)
+	}
+}
+
+// Flatten moves every Entry under srcRoot, such as in year and month folders, into destDir along with any sidecar files,
+// and returns how many were moved.
+// Each keeps its file name unless another file already has it, in which case it gets the first free suffix.
+// Entries already directly in destDir are left in place.
+func Flatten(srcRoot, destDir string) (int, error) {
+	if err := os.MkdirAll(destDir, 0777); err != nil {
+		return 0, err
+	}
+	absDest, err := filepath.Abs(destDir)
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	err = filepath.WalkDir(srcRoot, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !IsEntry(d.Name()) {
+			return err
+		}
+		if dir, err := filepath.Abs(filepath.Dir(path)); err != nil || dir == absDest {
+			return err
+		}
+		target, err := freePath(filepath.Join(destDir, d.Name()))
+		if err != nil {
+			return err
+		}
+		if err := moveEntry(OS, path, target); err != nil {
+			return err
+		}
+		moved++
+		return nil
+	})
+	return moved, err
+}
+
+// NeedsRating returns the entries in dir with at least minWords words whose moods aren't all set.
+func NeedsRating(dir string, minWords int) ([]*Entry, error) {
+	entries, err := Entries(dir)
+	var unrated []*Entry
+	for _, p := range entries {
+		if !p.Rated() && p.WordCount() >= minWords {
+			unrated = append(unrated, p)
+		}
+	}
+	return unrated, err
+}
+
+// NormalizeFilenames renames each Entry in dir whose file name differs from the canonical name for its date,
+// such as a mis-cased month or a name in the short format, and returns how many were renamed.
+// Same-day suffixes, compression, and sidecar files are kept. A file whose canonical name is already taken is left as it was,
+// and its error is joined into the returned error.
+func NormalizeFilenames(dir string) (int, error) {
+	paths, err := entryPaths(dir)
+	if err != nil {
+		return 0, err
+	}
+	renamed := 0
+	var errs []error
+	for _, path := range paths {
+		target, err := canonicalPath(path)
+		if err != nil || target == path {
+			continue
+		}
+		if err := moveEntry(OS, path, target); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		renamed++
+	}
+	return renamed, errors.Join(errs...)
+}
+
+// canonicalPath returns the path in path's directory with the default name for path's date, suffix, and compression.
+func canonicalPath(path string) (string, error) {
+	date, err := ParseDate(path)
+	if err != nil {
+		return "", err
+	}
+	return (&Entry{Path: path}).pathFor(date), nil
+}
+
+// RecentlyModified loads the entries in dir whose files were modified after since, whatever their dates,
+// most recently modified first. Files are checked with a stat, so only the matching ones are read.
+// As with Entries, files that fail to load are left out and their errors joined into the returned error.
+func RecentlyModified(dir string, since time.Time) ([]*Entry, error) {
+	paths, err := entryPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	var errs []error
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if !info.ModTime().After(since) {
+			continue
+		}
+		p := &Entry{Path: path}
+		if _, err := p.Load(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		entries = append(entries, p)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ModTime.After(entries[j].ModTime)
+	})
+	return entries, errors.Join(errs...)
+}
+
+// Ordinal returns p's 1-based position among the entries in dir in date order, and how many entries there are,
+// as in "entry 142 of 365". If p's file isn't in dir yet, p is counted where it would fall, so the total includes it.
+func (p *Entry) Ordinal(dir string) (position, total int, err error) {
+	paths, err := entryPaths(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	key := keyOf(filepath.Join(dir, filepath.Base(p.Path)))
+	i := sort.Search(len(paths), func(i int) bool { return !keyOf(paths[i]).less(key) })
+	if i < len(paths) && paths[i] == key.path {
+		return i + 1, len(paths), nil
+	}
+	return i + 1, len(paths) + 1, nil
+}
+
+// Nearest loads the Entry in dir whose date is closest to target's day, preferring the earlier date on a tie
+// and, among files sharing that date, the one without a suffix. Only that file is read.
+// It returns ErrNoEntries if dir has no entries.
+func Nearest(dir string, target time.Time) (*Entry, error) {
+	paths, err := entryPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	d := day(target)
+	var best string
+	var bestDate time.Time
+	for _, path := range paths {
+		date, err := ParseDate(path)
+		if err != nil {
+			continue
+		}
+		if best == "" || closer(date, bestDate, d) || (date.Equal(bestDate) && suffixOf(path) < suffixOf(best)) {
+			best, bestDate = path, date
+		}
+	}
+	if best == "" {
+		return nil, ErrNoEntries
+	}
+	p := &Entry{Path: best}
+	_, err = p.Load()
+	return p, err
+}
+
+// closer reports whether a is nearer to target than b, counting the earlier date as nearer on a tie.
+func closer(a, b, target time.Time) bool {
+	da, db := a.Sub(target), b.Sub(target)
+	if da < 0 {
+		da = -da
+	}
+	if db < 0 {
+		db = -db
+	}
+	return da < db || (da == db && a.Before(b))
+}
@@ -0,0 +1,529 @@
+package journalentry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntriesConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		writeEntry(t, dir, testDate.AddDate(0, 0, i), fmt.Sprintf("---\nseconds: %d\n---\nday %d\n", i, i))
+	}
+	bad := writeEntry(t, dir, testDate.AddDate(0, 0, 50), "---\nseconds: [\n---\n")
+	entries, err := EntriesConcurrent(dir, 8)
+	if err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("EntriesConcurrent error = %v, want one naming %s", err, bad)
+	}
+	if len(entries) != 50 {
+		t.Fatalf("EntriesConcurrent returned %d entries, want 50", len(entries))
+	}
+	for i, p := range entries {
+		if p.Seconds != uint32(i) {
+			t.Errorf("entries[%d].Seconds = %d, want entries in date order", i, p.Seconds)
+		}
+	}
+}
+
+func TestEntriesConcurrentJoinsErrors(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		writeEntry(t, dir, testDate.AddDate(0, 0, i), "---\nseconds: [\n---\n")
+	}
+	_, err := EntriesConcurrent(dir, 2)
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 3 {
+		t.Errorf("EntriesConcurrent error = %v, want 3 joined errors", err)
+	}
+}
+
+// benchmarkDir returns a directory of n entries of a few hundred words each.
+func benchmarkDir(b *testing.B, n int) string {
+	b.Helper()
+	dir := b.TempDir()
+	body := strings.Repeat("Some words written on the day. ", 50)
+	for i := 0; i < n; i++ {
+		path := entryPath(dir, testDate.AddDate(0, 0, i))
+		p := &Entry{Path: path, HighMood: 4, LowMood: 2, AverageMood: 3, Body: []byte(body)}
+		if err := p.Save(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func BenchmarkEntries(b *testing.B) {
+	dir := benchmarkDir(b, 1000)
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := EntriesConcurrent(dir, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFutureEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(-1, 0)...)
+	future, err := FutureEntries(dir, testDate.Add(15*time.Hour))
+	if err != nil || len(future) != 0 {
+		t.Errorf("FutureEntries without future entries = %v, %v", future, err)
+	}
+	writeEntries(t, dir, days(1, 30)...)
+	future, err = FutureEntries(dir, testDate.Add(15*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(future) != 2 || future[0].Path != entryPath(dir, testDate.AddDate(0, 0, 1)) {
+		t.Errorf("FutureEntries = %v, want the entries for days 1 and 30", paths(future))
+	}
+}
+
+// paths returns the paths of entries, for error messages.
+func paths(entries []*Entry) []string {
+	var out []string
+	for _, p := range entries {
+		out = append(out, p.Path)
+	}
+	return out
+}
+
+func TestEntriesByDay(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(-2, 0, 4, 29, 30)...)
+	suffixed := withSuffix(entryPath(dir, testDate.AddDate(0, 0, 4)), 2)
+	if err := os.WriteFile(suffixed, []byte("later\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	byDay, err := EntriesByDay(dir, 2020, time.January)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDay) != 3 || byDay[2] == nil || byDay[6] == nil || byDay[31] == nil {
+		t.Fatalf("EntriesByDay = %v, want days 2, 6, and 31", byDay)
+	}
+	if byDay[6].Path != suffixed {
+		t.Errorf("day 6 = %s, want the later entry %s", byDay[6].Path, suffixed)
+	}
+}
+
+func TestPinned(t *testing.T) {
+	dir := t.TempDir()
+	writeEntry(t, dir, testDate.AddDate(0, 0, 2), "---\npinned: true\n---\n")
+	writeEntry(t, dir, testDate.AddDate(0, 0, 1), "---\nhighmood: 3\n---\n")
+	writeEntry(t, dir, testDate, "---\npinned: true\n---\n")
+	pinned, err := Pinned(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{entryPath(dir, testDate), entryPath(dir, testDate.AddDate(0, 0, 2))}
+	if got := paths(pinned); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pinned = %v, want %v", got, want)
+	}
+}
+
+func TestAllEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(2, 0, 1)...)
+	var got []string
+	AllEntries(dir)(func(p *Entry, err error) bool {
+		if err != nil {
+			t.Error(err)
+		}
+		got = append(got, p.Path)
+		return true
+	})
+	want := []string{entryPath(dir, testDate), entryPath(dir, testDate.AddDate(0, 0, 1)), entryPath(dir, testDate.AddDate(0, 0, 2))}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AllEntries yielded %v, want %v", got, want)
+	}
+}
+
+func TestAllEntriesBreak(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 1, 2)...)
+	n := 0
+	AllEntries(dir)(func(p *Entry, err error) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("AllEntries yielded %d times after a break, want 1", n)
+	}
+}
+
+func TestAllEntriesYieldsErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeEntry(t, dir, testDate, "---\nhighmood: [\n---\n")
+	writeEntry(t, dir, testDate.AddDate(0, 0, 1), "")
+	var errs []error
+	var loaded []*Entry
+	AllEntries(dir)(func(p *Entry, err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			loaded = append(loaded, p)
+		}
+		return true
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), bad) || len(loaded) != 1 {
+		t.Errorf("AllEntries yielded errors %v and %d entries", errs, len(loaded))
+	}
+}
+
+func TestOnThisDay(t *testing.T) {
+	dir := t.TempDir()
+	ref := time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC)
+	var want []string
+	for _, year := range []int{2019, 2021, 2024} {
+		date := time.Date(year, time.March, 14, 0, 0, 0, 0, time.UTC)
+		writeEntry(t, dir, date, "")
+		want = append(want, entryPath(dir, date))
+	}
+	writeEntries(t, dir, time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC), time.Date(2022, time.April, 14, 0, 0, 0, 0, time.UTC))
+	found, err := OnThisDay(dir, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(found); !reflect.DeepEqual(got, want) {
+		t.Errorf("OnThisDay = %v, want %v", got, want)
+	}
+}
+
+func TestMigrateAll(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 1, 2)...)
+	bad := writeEntry(t, dir, testDate.AddDate(0, 0, 3), "---\nhighmood: [\n---\n")
+	err := MigrateAll(dir, func(p *Entry) error {
+		p.Tags = append(p.Tags, "migrated")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("MigrateAll error = %v, want one naming %s", err, bad)
+	}
+	entries, _ := Entries(dir)
+	if len(entries) != 3 {
+		t.Fatalf("Entries after MigrateAll = %v", paths(entries))
+	}
+	for _, p := range entries {
+		if !reflect.DeepEqual(p.Tags, []string{"migrated"}) {
+			t.Errorf("%s: Tags = %v, want [migrated]", p.Path, p.Tags)
+		}
+	}
+}
+
+func TestMigrateAllTransformError(t *testing.T) {
+	dir := t.TempDir()
+	path := writeEntry(t, dir, testDate, "unchanged\n")
+	err := MigrateAll(dir, func(p *Entry) error {
+		p.HighMood = 5
+		return errors.New("refused")
+	})
+	if err == nil {
+		t.Error("MigrateAll with a failing transform succeeded")
+	}
+	if got := readString(t, path); got != "unchanged\n" {
+		t.Errorf("file after a failed transform = %q", got)
+	}
+}
+
+func TestTempFilesAreNotEntries(t *testing.T) {
+	for _, name := range []string{
+		".2020-01-02-Journal-Entry-for-Jan-2.md.tmp",
+		".2020-01-02-Journal-Entry-for-Jan-2.md.123456",
+		"2020-01-02-Journal-Entry-for-Jan-2.md.bak",
+	} {
+		if IsEntry(name) {
+			t.Errorf("IsEntry(%q) = true", name)
+		}
+	}
+}
+
+func TestTotalWords(t *testing.T) {
+	dir := t.TempDir()
+	writeEntry(t, dir, testDate, "one two three\n")
+	writeEntry(t, dir, testDate.AddDate(0, 0, 1), "---\nhighmood: 3\n---\nfour five\n")
+	writeEntry(t, dir, testDate.AddDate(0, 0, 2), "")
+	bad := writeEntry(t, dir, testDate.AddDate(0, 0, 3), "---\nhighmood: [\n---\nskipped words\n")
+	total, err := TotalWords(dir)
+	if total != 5 {
+		t.Errorf("TotalWords = %d, want 5", total)
+	}
+	if err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("TotalWords error = %v, want one naming %s", err, bad)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	root := t.TempDir()
+	dest := filepath.Join(root, "all")
+	for _, dir := range []string{"2020/01", "2020/02", "2021/01"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeEntry(t, filepath.Join(root, "2020/01"), testDate, "first\n")
+	writeEntry(t, filepath.Join(root, "2020/02"), testDate.AddDate(0, 1, 0), "second\n")
+	writeEntry(t, filepath.Join(root, "2021/01"), testDate, "copy\n")
+	if err := os.WriteFile(filepath.Join(root, "2020/01", "notes.txt"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := Flatten(root, dest)
+	if err != nil || moved != 3 {
+		t.Fatalf("Flatten = %d, %v, want 3", moved, err)
+	}
+	entries, err := Entries(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bodies []string
+	for _, p := range entries {
+		bodies = append(bodies, filepath.Base(p.Path)+": "+string(p.Body))
+	}
+	want := []string{
+		"2020-01-02-Journal-Entry-for-Jan-2.md: first\n",
+		"2020-01-02-Journal-Entry-for-Jan-2-2.md: copy\n",
+		"2020-02-02-Journal-Entry-for-Feb-2.md: second\n",
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("flattened entries = %q, want %q", bodies, want)
+	}
+	if _, err := os.Stat(filepath.Join(root, "2020/01", "notes.txt")); err != nil {
+		t.Errorf("non-entry file moved: %v", err)
+	}
+}
+
+func TestNeedsRating(t *testing.T) {
+	dir := t.TempDir()
+	long := strings.Repeat("word ", 20)
+	unrated := writeEntry(t, dir, testDate, "---\nhighmood: 4\n---\n"+long)
+	writeEntry(t, dir, testDate.AddDate(0, 0, 1), "---\nhighmood: 4\nlowmood: 2\naveragemood: 3\n---\n"+long)
+	writeEntry(t, dir, testDate.AddDate(0, 0, 2), "short\n")
+	found, err := NeedsRating(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(found); !reflect.DeepEqual(got, []string{unrated}) {
+		t.Errorf("NeedsRating = %v, want [%s]", got, unrated)
+	}
+}
+
+func TestNormalizeFilenames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"2020-01-02-Journal-Entry-for-jan-2.md",
+		"2020-01-03-Journal-Entry.md",
+		"2020-01-04-Journal-Entry-2.md.gz",
+		"2020-01-05-Journal-Entry-for-Jan-5.md",
+		// The canonical name for the 5th is taken.
+		"2020-01-05-Journal-Entry.md",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := NormalizeFilenames(dir)
+	if n != 3 {
+		t.Errorf("NormalizeFilenames renamed %d files, want 3", n)
+	}
+	if err == nil || !strings.Contains(err.Error(), "2020-01-05-Journal-Entry.md") {
+		t.Errorf("NormalizeFilenames error = %v, want the collision on the 5th", err)
+	}
+	var names []string
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{
+		"2020-01-02-Journal-Entry-for-Jan-2.md",
+		"2020-01-03-Journal-Entry-for-Jan-3.md",
+		"2020-01-04-Journal-Entry-for-Jan-4-2.md.gz",
+		"2020-01-05-Journal-Entry-for-Jan-5.md",
+		"2020-01-05-Journal-Entry.md",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("files after NormalizeFilenames = %v, want %v", names, want)
+	}
+	if got := readString(t, filepath.Join(dir, want[1])); got != "2020-01-03-Journal-Entry.md" {
+		t.Errorf("renamed file holds %q", got)
+	}
+	if got := readString(t, filepath.Join(dir, want[3])); got != want[3] {
+		t.Errorf("file at a taken canonical name holds %q, want it untouched", got)
+	}
+}
+
+func TestHeatmapData(t *testing.T) {
+	dir := t.TempDir()
+	writeEntry(t, dir, testDate, "---\n---\none two three\n")
+	second := writeEntry(t, dir, testDate.AddDate(0, 0, 1), "---\n---\nfour\n")
+	if err := os.WriteFile(withSuffix(second, 2), []byte("five six\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	writeEntry(t, dir, testDate.AddDate(0, 3, 0), "")
+	// Entries in other years are left out.
+	writeEntry(t, dir, testDate.AddDate(-1, 0, 0), "---\n---\nlast year\n")
+	writeEntry(t, dir, time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), "---\n---\nnext year\n")
+
+	got, err := HeatmapData(dir, 2020)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[time.Time]int{
+		testDate:                  3,
+		testDate.AddDate(0, 0, 1): 3,
+		testDate.AddDate(0, 3, 0): 0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HeatmapData = %v, want %v", got, want)
+	}
+}
+
+func TestRecentlyModified(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	touch := func(path string, ago time.Duration) {
+		t.Helper()
+		if err := os.Chtimes(path, now.Add(-ago), now.Add(-ago)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := writeEntry(t, dir, testDate, "")
+	touch(old, 48*time.Hour)
+	recent := writeEntry(t, dir, testDate.AddDate(0, 0, 1), "")
+	touch(recent, 2*time.Hour)
+	newest := writeEntry(t, dir, testDate.AddDate(-1, 0, 0), "")
+	touch(newest, time.Hour)
+	// An old file isn't loaded, so its broken frontmatter doesn't matter.
+	broken := writeEntry(t, dir, testDate.AddDate(0, 0, 2), "---\nhighmood: [\n---\n")
+	touch(broken, 72*time.Hour)
+
+	entries, err := RecentlyModified(dir, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{newest, recent}; !reflect.DeepEqual(paths(entries), want) {
+		t.Errorf("RecentlyModified = %v, want %v, most recently modified first", paths(entries), want)
+	}
+}
+
+func TestDirectoryNamedLikeEntry(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 2)...)
+	if err := os.Mkdir(entryPath(dir, testDate.AddDate(0, 0, 1)), 0777); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{entryPath(dir, testDate), entryPath(dir, testDate.AddDate(0, 0, 2))}
+	entries, err := Entries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths(entries), want) {
+		t.Errorf("Entries = %v, want %v", paths(entries), want)
+	}
+	var walked []string
+	AllEntries(dir)(func(p *Entry, err error) bool {
+		if err != nil {
+			t.Error(err)
+		} else {
+			walked = append(walked, p.Path)
+		}
+		return true
+	})
+	if !reflect.DeepEqual(walked, want) {
+		t.Errorf("AllEntries = %v, want %v", walked, want)
+	}
+	// The directory doesn't bridge the gap between the two entries.
+	if length, _, _, err := LongestStreak(dir); err != nil || length != 1 {
+		t.Errorf("LongestStreak = %d, %v, want 1", length, err)
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 3, 7, 10, 20)...)
+	for _, tt := range []struct {
+		name            string
+		date            time.Time
+		position, total int
+	}{
+		{"middle", testDate.AddDate(0, 0, 7), 3, 5},
+		{"first", testDate, 1, 5},
+		{"last", testDate.AddDate(0, 0, 20), 5, 5},
+		{"not saved yet", testDate.AddDate(0, 0, 5), 3, 6},
+		{"not saved yet, after all", testDate.AddDate(0, 0, 30), 6, 6},
+	} {
+		p := &Entry{Path: entryPath(dir, tt.date)}
+		position, total, err := p.Ordinal(dir)
+		if err != nil || position != tt.position || total != tt.total {
+			t.Errorf("Ordinal of %s = %d of %d, %v, want %d of %d", tt.name, position, total, err, tt.position, tt.total)
+		}
+	}
+}
+
+func TestSameDaySuffixOrder(t *testing.T) {
+	dir := t.TempDir()
+	first := entryPath(dir, testDate)
+	want := []string{first, withSuffix(first, 2), withSuffix(first, 10), entryPath(dir, testDate.AddDate(0, 0, 1))}
+	for _, path := range want {
+		if err := os.WriteFile(path, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := Entries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths(entries), want) {
+		t.Errorf("Entries = %v, want %v", paths(entries), want)
+	}
+	for i, path := range want {
+		position, total, err := (&Entry{Path: path}).Ordinal(dir)
+		if err != nil || position != i+1 || total != len(want) {
+			t.Errorf("Ordinal of %s = %d of %d, %v, want %d of %d", filepath.Base(path), position, total, err, i+1, len(want))
+		}
+	}
+	if position, total, err := (&Entry{Path: withSuffix(first, 3)}).Ordinal(dir); err != nil || position != 3 || total != 5 {
+		t.Errorf("Ordinal of an unsaved -3 = %d of %d, %v, want 3 of 5", position, total, err)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 4, 10)...)
+	if err := os.WriteFile(withSuffix(entryPath(dir, testDate.AddDate(0, 0, 4)), 2), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name   string
+		target time.Time
+		want   time.Time
+	}{
+		{"exact", testDate.AddDate(0, 0, 10), testDate.AddDate(0, 0, 10)},
+		{"closer after", testDate.AddDate(0, 0, 3), testDate.AddDate(0, 0, 4)},
+		{"closer before", testDate.AddDate(0, 0, 6), testDate.AddDate(0, 0, 4)},
+		{"tie goes earlier", testDate.AddDate(0, 0, 7), testDate.AddDate(0, 0, 4)},
+		{"time of day ignored", testDate.AddDate(0, 0, 2).Add(23 * time.Hour), testDate},
+		{"before all", testDate.AddDate(-1, 0, 0), testDate},
+		{"after all", testDate.AddDate(1, 0, 0), testDate.AddDate(0, 0, 10)},
+	} {
+		p, err := Nearest(dir, tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := entryPath(dir, tt.want); p.Path != want {
+			t.Errorf("Nearest %s = %s, want %s", tt.name, p.Path, want)
+		}
+	}
+	if _, err := Nearest(t.TempDir(), testDate); !errors.Is(err, ErrNoEntries) {
+		t.Errorf("Nearest in an empty directory = %v, want ErrNoEntries", err)
+	}
+}
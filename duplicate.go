@@ -0,0 +1,65 @@
+package journalentry
+
+import (
+	"bytes"
+	"time"
+	"unicode"
+)
+
+// DuplicatePair is a pair of entries on consecutive days whose bodies are alike, such as a copy of yesterday's entry.
+type DuplicatePair struct {
+	Earlier, Later time.Time
+	// Similarity is the overlap of the two bodies' vocabularies, from 0 (no words shared) to 1 (the same words).
+	Similarity float64
+}
+
+// NearDuplicate returns the pairs of entries in dir on consecutive days whose similarity is at least threshold, in date order.
+// Similarity is the Jaccard index of the sets of words in each body, ignoring case and surrounding punctuation;
+// an entry with no words is alike to none.
+// As with Entries, files that fail to load are left out and their errors returned.
+func NearDuplicate(dir string, threshold float64) ([]DuplicatePair, error) {
+	entries, err := Entries(dir)
+	var pairs []DuplicatePair
+	var prevDate time.Time
+	var prevWords map[string]bool
+	for _, p := range entries {
+		date, dateErr := p.Date()
+		if dateErr != nil {
+			continue
+		}
+		words := vocabulary(p)
+		if prevWords != nil && prevDate.AddDate(0, 0, 1).Equal(date) {
+			if s := jaccard(prevWords, words); s >= threshold && s > 0 {
+				pairs = append(pairs, DuplicatePair{Earlier: prevDate, Later: date, Similarity: s})
+			}
+		}
+		prevDate, prevWords = date, words
+	}
+	return pairs, err
+}
+
+// vocabulary returns the distinct words in p's body, lowercased and trimmed of punctuation.
+func vocabulary(p *Entry) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range p.Words() {
+		if w = bytes.ToLower(bytes.TrimFunc(w, unicode.IsPunct)); len(w) > 0 {
+			words[string(w)] = true
+		}
+	}
+	return words
+}
+
+// jaccard returns the size of the intersection of a and b over the size of their union, or 0 if both are empty.
+func jaccard(a, b map[string]bool) float64 {
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
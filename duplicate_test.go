@@ -0,0 +1,41 @@
+package journalentry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNearDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	d := days(0, 1, 2, 4)
+	writeEntry(t, dir, d[0], "---\n---\nWent for a walk in the park.\n")
+	writeEntry(t, dir, d[1], "---\n---\nwent for a walk in the PARK!\n")
+	writeEntry(t, dir, d[2], "---\n---\nWorked late on the report.\n")
+	// A copy two days later isn't on a consecutive day.
+	writeEntry(t, dir, d[3], "---\n---\nWorked late on the report.\n")
+
+	pairs, err := NearDuplicate(dir, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 || !pairs[0].Earlier.Equal(d[0]) || !pairs[0].Later.Equal(d[1]) || pairs[0].Similarity != 1 {
+		t.Fatalf("NearDuplicate(0.8) = %+v, want only the copy on days 0 and 1", pairs)
+	}
+
+	pairs, err = NearDuplicate(dir, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One shared word, "the", out of 11 distinct.
+	if len(pairs) != 2 || !pairs[1].Earlier.Equal(d[1]) || math.Abs(pairs[1].Similarity-1.0/11) > 1e-9 {
+		t.Errorf("NearDuplicate(0.05) = %+v, want a second, distinct pair with similarity 1/11", pairs)
+	}
+}
+
+func TestNearDuplicateEmptyBodies(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 1)...)
+	if pairs, err := NearDuplicate(dir, 0); err != nil || len(pairs) != 0 {
+		t.Errorf("NearDuplicate of empty entries = %+v, %v, want none", pairs, err)
+	}
+}
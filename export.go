@@ -0,0 +1,173 @@
+package journalentry
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// CombinedOptions controls ExportCombined.
+type CombinedOptions struct {
+	// Metadata adds a line with each entry's moods and word count below its heading.
+	Metadata bool
+	// IncludePrivate includes entries marked Private, which are otherwise left out.
+	IncludePrivate bool
+}
+
+// exportable returns the entries that exports should include, in date order.
+func exportable(entries []*Entry, includePrivate bool) []*Entry {
+	var out []*Entry
+	for _, p := range entries {
+		if includePrivate || !p.Private {
+			out = append(out, p)
+		}
+	}
+	SortEntries(out, ByDate)
+	return out
+}
+
+// ExportCombined writes entries to w as a single Markdown document in date order.
+// Each entry appears under its Title as a heading, and entries are separated by a horizontal rule.
+// Private entries are left out unless opts.IncludePrivate is set.
+func ExportCombined(entries []*Entry, w io.Writer, opts CombinedOptions) error {
+	for i, p := range exportable(entries, opts.IncludePrivate) {
+		title, err := p.Title()
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n* * *\n\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n\n", title); err != nil {
+			return err
+		}
+		if opts.Metadata {
+			if _, err := fmt.Fprintf(w, "_High mood %d, low mood %d, average mood %d, %d words_\n\n",
+				p.HighMood, p.LowMood, p.AverageMood, p.WordCount()); err != nil {
+				return err
+			}
+		}
+		body := bytes.TrimRight(p.Body, "\n")
+		if _, err := fmt.Fprintf(w, "%s\n", body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atomFeed and atomEntry are the parts of an Atom (RFC 4287) document written by ExportAtom.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// ExportAtom writes the most recent limit entries to w as an Atom feed, newest first, or all of them if limit isn't positive.
+// Each feed entry has the entry's Title, its ModTime (or its date, if it has none) as the update time, and its body
+// rendered as HTML by RenderHTML. Private entries are left out.
+func ExportAtom(entries []*Entry, w io.Writer, limit int) error {
+	public := exportable(entries, false)
+	if limit > 0 && len(public) > limit {
+		public = public[len(public)-limit:]
+	}
+	feed := atomFeed{ID: "urn:journalentry:feed", Title: "Journal", Author: "Journal"}
+	var latest time.Time
+	for i := len(public) - 1; i >= 0; i-- {
+		p := public[i]
+		title, err := p.Title()
+		if err != nil {
+			return err
+		}
+		updated, _ := p.Date()
+		if !p.ModTime.IsZero() {
+			updated = p.ModTime
+		}
+		if updated.After(latest) {
+			latest = updated
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      "urn:journalentry:" + filepath.Base(p.Path),
+			Title:   title,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Content: atomContent{Type: "html", Body: RenderHTML(p.Body)},
+		})
+	}
+	feed.Updated = latest.UTC().Format(time.RFC3339)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// htmlPage is the document ExportHTMLPage writes.
+var htmlPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 40em; margin: 2em auto; padding: 0 1em; font: 1.1em/1.6 Georgia, serif; color: #222; }
+time, footer { color: #666; font-size: 0.85em; }
+footer { margin-top: 3em; border-top: 1px solid #ddd; padding-top: 0.5em; }
+</style>
+</head>
+<body>
+<article>
+<h1>{{.Title}}</h1>
+<time datetime="{{.Date.Format "2006-01-02"}}">{{.Date.Format "Monday, January 2, 2006"}}</time>
+{{.Body}}</article>
+<footer>High mood {{.High}}, low mood {{.Low}}, average mood {{.Average}}. {{.Words}} words.</footer>
+</body>
+</html>
+`))
+
+// ExportHTMLPage writes p to w as a self-contained HTML document, with its Title, date, and body rendered by RenderHTML,
+// followed by a footer with its moods and word count.
+// Unlike the exports of several entries, it writes p even if it is Private.
+func (p *Entry) ExportHTMLPage(w io.Writer) error {
+	title, err := p.Title()
+	if err != nil {
+		return err
+	}
+	date, err := p.Date()
+	if err != nil {
+		return err
+	}
+	p.mu.RLock()
+	data := struct {
+		Title              string
+		Date               time.Time
+		Body               template.HTML
+		High, Low, Average uint8
+		Words              int
+	}{title, date, template.HTML(RenderHTML(p.Body)), p.HighMood, p.LowMood, p.AverageMood, len(p.words())}
+	p.mu.RUnlock()
+	return htmlPage.Execute(w, data)
+}
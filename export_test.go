@@ -0,0 +1,120 @@
+package journalentry
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestExportCombined(t *testing.T) {
+	entries := []*Entry{
+		withBody(testDate.AddDate(0, 0, 2), "Third.\n"),
+		withBody(testDate, "First.\n"),
+		withBody(testDate.AddDate(0, 0, 1), "Second day.\n"),
+	}
+	entries[1].HighMood, entries[1].LowMood, entries[1].AverageMood = 4, 2, 3
+	var buf strings.Builder
+	if err := ExportCombined(entries, &buf, CombinedOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Journal Entry for January 2, 2020\n\nFirst.\n" +
+		"\n* * *\n\n# Journal Entry for January 3, 2020\n\nSecond day.\n" +
+		"\n* * *\n\n# Journal Entry for January 4, 2020\n\nThird.\n"
+	if buf.String() != want {
+		t.Errorf("ExportCombined = %q, want %q", buf.String(), want)
+	}
+	buf.Reset()
+	if err := ExportCombined(entries, &buf, CombinedOptions{Metadata: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "# Journal Entry for January 2, 2020\n\n_High mood 4, low mood 2, average mood 3, 1 words_\n\nFirst.\n") {
+		t.Errorf("ExportCombined with metadata = %q", buf.String())
+	}
+}
+
+func TestExportCombinedPrivate(t *testing.T) {
+	public := withBody(testDate, "Public.\n")
+	private := withBody(testDate.AddDate(0, 0, 1), "Secret.\n")
+	private.Private = true
+	var buf strings.Builder
+	if err := ExportCombined([]*Entry{public, private}, &buf, CombinedOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Secret.") || !strings.Contains(buf.String(), "Public.") {
+		t.Errorf("ExportCombined = %q, want the private entry left out", buf.String())
+	}
+	buf.Reset()
+	if err := ExportCombined([]*Entry{public, private}, &buf, CombinedOptions{IncludePrivate: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Secret.") {
+		t.Errorf("ExportCombined with IncludePrivate = %q, want the private entry", buf.String())
+	}
+}
+
+func TestExportAtom(t *testing.T) {
+	var entries []*Entry
+	for i, body := range []string{"first", "second", "secret", "fourth <b>bold</b>\n\n## Later\n\n- a *list*\n"} {
+		entries = append(entries, withBody(testDate.AddDate(0, 0, i), body))
+	}
+	entries[2].Private = true
+	var out strings.Builder
+	if err := ExportAtom(entries, &out, 2); err != nil {
+		t.Fatal(err)
+	}
+	var feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			Title   string `xml:"title"`
+			Updated string `xml:"updated"`
+			Content struct {
+				Type string `xml:"type,attr"`
+				Body string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal([]byte(out.String()), &feed); err != nil {
+		t.Fatalf("ExportAtom wrote invalid XML: %v\n%s", err, out.String())
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("feed has %d entries, want 2", len(feed.Entries))
+	}
+	newest, second := feed.Entries[0], feed.Entries[1]
+	if newest.Title != "Journal Entry for January 5, 2020" || second.Title != "Journal Entry for January 3, 2020" {
+		t.Errorf("feed entry titles = %q, %q, want the two newest public entries, newest first", newest.Title, second.Title)
+	}
+	if newest.Updated != "2020-01-05T00:00:00Z" || feed.Updated != newest.Updated {
+		t.Errorf("updated = %s, feed updated = %s, want 2020-01-05T00:00:00Z", newest.Updated, feed.Updated)
+	}
+	if want := "<p>fourth &lt;b&gt;bold&lt;/b&gt;</p>\n<h2>Later</h2>\n<ul>\n<li>a <em>list</em></li>\n</ul>\n"; newest.Content.Type != "html" || newest.Content.Body != want {
+		t.Errorf("content = %s %q, want html %q", newest.Content.Type, newest.Content.Body, want)
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Error("feed includes a private entry")
+	}
+}
+
+func TestExportHTMLPage(t *testing.T) {
+	p := withBody(testDate, "First <em>line</em>.\n\n## Evening\n\nSecond **paragraph**.\n")
+	p.Headline, p.Private = "A & B", true
+	p.HighMood, p.LowMood, p.AverageMood = 5, 2, 4
+	var out strings.Builder
+	if err := p.ExportHTMLPage(&out); err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>A &amp; B</title>",
+		"<h1>A &amp; B</h1>",
+		`<time datetime="2020-01-02">Thursday, January 2, 2020</time>`,
+		"<p>First &lt;em&gt;line&lt;/em&gt;.</p>\n<h2>Evening</h2>\n<p>Second <strong>paragraph</strong>.</p>",
+		"<style>",
+		"High mood 5, low mood 2, average mood 4. 6 words.",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page doesn't contain %q:\n%s", want, page)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package journalentry
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// filenameTokens are the placeholders a FilenameTemplate may contain besides Go time layout elements,
+// each with a pattern matching its values and a function producing the value for a date.
+var filenameTokens = map[string]struct {
+	pattern string
+	value   func(time.Time) string
+}{
+	"{weekday}": {`[a-z]+day`, func(t time.Time) string { return strings.ToLower(t.Weekday().String()) }},
+	"{isoweek}": {`\d{2}`, func(t time.Time) string { _, w := t.ISOWeek(); return fmt.Sprintf("%02d", w) }},
+}
+
+// FilenameTemplate names entry files using a Go time layout that may also contain the tokens
+// {weekday}, the lowercase day of the week, and {isoweek}, the two-digit ISO week number.
+type FilenameTemplate struct {
+	layout string         // the template with the tokens removed
+	parts  []string       // the template split into layout text and tokens
+	regex  *regexp.Regexp // matches names, capturing the text of each layout part
+}
+
+// NewFilenameTemplate returns the FilenameTemplate for tmpl, such as "2006-01-02-{weekday}.md".
+// It returns an error if names produced by tmpl can't be parsed back into their dates.
+func NewFilenameTemplate(tmpl string) (*FilenameTemplate, error) {
+	if strings.ContainsRune(tmpl, filepath.Separator) {
+		return nil, errors.New("filename template must not contain a path separator")
+	}
+	t := &FilenameTemplate{}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for rest := tmpl; rest != ""; {
+		i, token := len(rest), ""
+		for tok := range filenameTokens {
+			if j := strings.Index(rest, tok); j >= 0 && j < i {
+				i, token = j, tok
+			}
+		}
+		if i > 0 {
+			t.parts = append(t.parts, rest[:i])
+			t.layout += rest[:i]
+			pattern.WriteString("(" + layoutPattern(rest[:i]) + ")")
+		}
+		if token != "" {
+			t.parts = append(t.parts, token)
+			pattern.WriteString(filenameTokens[token].pattern)
+		}
+		rest = rest[i+len(token):]
+	}
+	pattern.WriteString("$")
+	t.regex = regexp.MustCompile(pattern.String())
+	// A date with distinct year, month, and day digits reveals a layout missing any of them.
+	sample := time.Date(2017, time.November, 23, 0, 0, 0, 0, time.UTC)
+	if date, err := t.Parse(t.Format(sample)); err != nil || !date.Equal(sample) {
+		return nil, fmt.Errorf("filename template %q doesn't produce parseable dates", tmpl)
+	}
+	return t, nil
+}
+
+// layoutElements are the date elements of Go time layouts a FilenameTemplate understands, longest first,
+// with patterns matching their values.
+var layoutElements = []struct{ element, pattern string }{
+	{"January", `[A-Za-z]+`},
+	{"Monday", `[A-Za-z]+`},
+	{"2006", `\d{4}`},
+	{"Jan", `[A-Za-z]{3}`},
+	{"Mon", `[A-Za-z]{3}`},
+	{"002", `\d{3}`},
+	{"01", `\d{2}`},
+	{"02", `\d{2}`},
+	{"06", `\d{2}`},
+	{"_2", `[ \d]\d`},
+	{"1", `\d{1,2}`},
+	{"2", `\d{1,2}`},
+}
+
+// layoutPattern returns a regular expression matching the text layout formats dates as.
+func layoutPattern(layout string) string {
+	var b strings.Builder
+	for layout != "" {
+		matched := false
+		for _, e := range layoutElements {
+			if strings.HasPrefix(layout, e.element) {
+				b.WriteString(e.pattern)
+				layout = layout[len(e.element):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteString(regexp.QuoteMeta(layout[:1]))
+			layout = layout[1:]
+		}
+	}
+	return b.String()
+}
+
+// Format returns the file name for date.
+func (t *FilenameTemplate) Format(date time.Time) string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if tok, ok := filenameTokens[part]; ok {
+			b.WriteString(tok.value(date))
+		} else {
+			b.WriteString(date.Format(part))
+		}
+	}
+	return b.String()
+}
+
+// Parse returns the date in name, the base name of a file produced by Format.
+func (t *FilenameTemplate) Parse(name string) (time.Time, error) {
+	m := t.regex.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("%s is not named like an entry", name)
+	}
+	date, err := time.Parse(t.layout, strings.Join(m[1:], ""))
+	if err != nil {
+		return date, err
+	}
+	if t.Format(date) != name {
+		return time.Time{}, fmt.Errorf("%s is not named like an entry", name)
+	}
+	return date, nil
+}
+
+// IsEntry reports whether the base name of path is one produced by t.
+func (t *FilenameTemplate) IsEntry(path string) bool {
+	_, err := t.Parse(filepath.Base(path))
+	return err == nil
+}
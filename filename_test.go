@@ -0,0 +1,89 @@
+package journalentry
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFilenameTemplate(t *testing.T) {
+	tmpl, err := NewFilenameTemplate("2006-01-02-{weekday}.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tmpl.Format(testDate); got != "2020-01-02-thursday.md" {
+		t.Errorf("Format = %q", got)
+	}
+	date, err := tmpl.Parse("2020-01-02-thursday.md")
+	if err != nil || !date.Equal(testDate) {
+		t.Errorf("Parse = %v, %v, want %v", date, err, testDate)
+	}
+	for name, want := range map[string]bool{
+		"journal/2020-01-02-thursday.md": true,
+		"2020-01-02-friday.md":           false,
+		"2020-01-02-thursday.md.tmp":     false,
+		"2020-01-02.md":                  false,
+	} {
+		if got := tmpl.IsEntry(name); got != want {
+			t.Errorf("IsEntry(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFilenameTemplateISOWeek(t *testing.T) {
+	tmpl, err := NewFilenameTemplate("2006/W{isoweek}/Jan-02.md")
+	if err == nil {
+		t.Errorf("template with a path separator = %v, want an error", tmpl)
+	}
+	tmpl, err = NewFilenameTemplate("2006-01-02-W{isoweek}.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tmpl.Format(testDate); got != "2020-01-02-W01.md" {
+		t.Errorf("Format = %q", got)
+	}
+}
+
+func TestFilenameTemplateUnparseable(t *testing.T) {
+	for _, tmpl := range []string{"journal-{weekday}.md", "2006-01.md", "01-02.md"} {
+		if _, err := NewFilenameTemplate(tmpl); err == nil {
+			t.Errorf("NewFilenameTemplate(%q) succeeded", tmpl)
+		}
+	}
+}
+
+func TestNewWithFilename(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := NewFilenameTemplate("2006-01-02-{weekday}.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewWithFilename(dir, tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, tmpl.Format(time.Now())); p.Path != want {
+		t.Errorf("Path = %s, want %s", p.Path, want)
+	}
+	if date, err := p.Date(); err != nil || !date.Equal(day(time.Now())) {
+		t.Errorf("Date = %v, %v", date, err)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{
+		"2024-01-02-Journal-Entry-for-Jan-2.md",
+		"2024-01-02-Journal-Entry.md",
+		filepath.Join("journal", "2024-01-02-Journal-Entry-2.md.gz"),
+	} {
+		if date, err := ParseDate(name); err != nil || !date.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, %v, want %v", name, date, err, want)
+		}
+	}
+	for _, name := range []string{"2024-01-02-Diary.md", "notes.txt", ""} {
+		if date, err := ParseDate(name); err == nil {
+			t.Errorf("ParseDate(%q) = %v, want an error", name, date)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package journalentry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FS is the filesystem entries are read from and written to.
+// OS, the default, is the local disk; other implementations can keep entries in memory or remote storage.
+type FS interface {
+	Open(name string) (fs.File, error)
+	Create(name string) (io.WriteCloser, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Rename(oldname, newname string) error
+	Remove(name string) error
+}
+
+// OS is the FS backed by the os package.
+var OS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) Create(name string) (io.WriteCloser, error) { return os.Create(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFS) Rename(oldname, newname string) error       { return os.Rename(oldname, newname) }
+func (osFS) Remove(name string) error                   { return os.Remove(name) }
+
+// fsOrOS returns fsys, or OS if fsys is nil.
+func fsOrOS(fsys FS) FS {
+	if fsys == nil {
+		return OS
+	}
+	return fsys
+}
+
+// readFile returns the contents of name in fsys.
+func readFile(fsys FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// writeFile writes data to name in fsys, creating or truncating it.
+func writeFile(fsys FS, name string, data []byte) error {
+	f, err := fsys.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFileRenaming writes data to a temporary name beside path in fsys and renames it into place.
+// It is the atomic save for filesystems other than OS, which can't set permissions or choose unique names.
+func writeFileRenaming(fsys FS, path string, data []byte) error {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := writeFile(fsys, tmp, data); err != nil {
+		fsys.Remove(tmp)
+		return err
+	}
+	return fsys.Rename(tmp, path)
+}
+
+// renameIfFree renames path to target in fsys unless another file is already there.
+// On OS the rename is done by hard-linking target first, so a file created there in the meantime is never replaced;
+// where links aren't supported, and on other filesystems, target is checked with Stat before renaming.
+// A target that is path itself under another name, as on a case-insensitive filesystem, doesn't count as taken.
+func renameIfFree(fsys FS, path, target string) error {
+	if fsys == OS {
+		if err := os.Link(path, target); err == nil {
+			return os.Remove(path)
+		}
+	}
+	if info, err := fsys.Stat(target); err == nil {
+		if src, err := fsys.Stat(path); err != nil || !os.SameFile(src, info) {
+			return fmt.Errorf("cannot rename to %s: file already exists", target)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return fsys.Rename(path, target)
+}
+
+// moveEntry renames the Entry file at path to target in fsys as renameIfFree does, along with its sidecar file if it has one.
+// If the sidecar can't be moved, the Entry file is moved back.
+func moveEntry(fsys FS, path, target string) error {
+	if err := renameIfFree(fsys, path, target); err != nil {
+		return err
+	}
+	if _, err := fsys.Stat(sidecarPath(path)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err := renameIfFree(fsys, sidecarPath(path), sidecarPath(target)); err != nil {
+		return errors.Join(err, fsys.Rename(target, path))
+	}
+	return nil
+}
+
+// removeEntry removes the Entry file at path from fsys, along with its sidecar file if it has one.
+func removeEntry(fsys FS, path string) error {
+	if err := fsys.Remove(path); err != nil {
+		return err
+	}
+	if err := fsys.Remove(sidecarPath(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package journalentry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// memFS is an FS held in memory, with paths relative to an implicit root.
+type memFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+func newMemFS(dirs ...string) *memFS {
+	m := &memFS{files: fstest.MapFS{}}
+	for _, dir := range dirs {
+		m.files[dir] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
+	}
+	return m
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.Open(name)
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.Stat(name)
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.ReadDir(name)
+}
+
+func (m *memFS) Create(name string) (io.WriteCloser, error) {
+	return &memFile{fsys: m, name: name}, nil
+}
+
+func (m *memFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[oldname]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrNotExist}
+	}
+	delete(m.files, oldname)
+	m.files[newname] = f
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+// memFile is a file being written to a memFS, stored when it is closed.
+type memFile struct {
+	fsys *memFS
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) { return f.buf.Write(p) }
+
+func (f *memFile) Close() error {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	f.fsys.files[f.name] = &fstest.MapFile{Data: f.buf.Bytes(), Mode: 0666, ModTime: time.Now()}
+	return nil
+}
+
+func TestMemFS(t *testing.T) {
+	mem := newMemFS("journal")
+	mem.files[entryPath("journal", testDate)] = &fstest.MapFile{Data: []byte("---\nhighmood: 4\nlowmood: 2\naveragemood: 3\n---\nan old day\n")}
+
+	p, err := NewFS(mem, "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.FS != mem {
+		t.Fatal("NewFS returned an Entry without its FS")
+	}
+	p.Body = []byte("written in memory\n")
+	p.SetHighMood(5)
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("journal"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("NewFS and Save touched the disk: %v", err)
+	}
+	if got := string(mem.files[p.Path].Data); !strings.Contains(got, "highmood: 5\n") || !strings.HasSuffix(got, "written in memory\n") {
+		t.Errorf("saved file = %q", got)
+	}
+	if _, ok := mem.files["journal/.journalentry-probe"]; ok {
+		t.Error("NewFS left its writability probe behind")
+	}
+
+	loaded := &Entry{Path: p.Path, FS: mem}
+	if _, err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HighMood != 5 || string(loaded.Body) != "written in memory\n" {
+		t.Errorf("Load = HighMood %d, Body %q", loaded.HighMood, loaded.Body)
+	}
+	if diff, err := loaded.Diff(); err != nil || diff != "" {
+		t.Errorf("Diff of a loaded Entry = %q, %v", diff, err)
+	}
+	if ok, err := loaded.VerifyRoundTrip(); !ok || err != nil {
+		t.Errorf("VerifyRoundTrip = %t, %v", ok, err)
+	}
+	meta := &Entry{Path: entryPath("journal", testDate), FS: mem}
+	if err := meta.LoadMetadata(); err != nil || meta.AverageMood != 3 || len(meta.Body) != 0 {
+		t.Errorf("LoadMetadata = %v, AverageMood %d, Body %q", err, meta.AverageMood, meta.Body)
+	}
+
+	entries, err := EntriesFS(mem, "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{entryPath("journal", testDate), p.Path}; !reflect.DeepEqual(paths(entries), want) {
+		t.Errorf("EntriesFS = %v, want %v", paths(entries), want)
+	}
+
+	j := Journal{Dir: "journal", FS: mem}
+	if found, err := j.Search("MEMORY"); err != nil || len(found) != 1 || found[0].Path != p.Path {
+		t.Errorf("Search(MEMORY) = %v, %v", paths(found), err)
+	}
+	s, err := j.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Entries != 2 || s.Words != 6 || s.RatingCoverage != 0.5 {
+		t.Errorf("Stats = %+v", s)
+	}
+}
+
+func TestMemFSAtomicSaveAndRename(t *testing.T) {
+	mem := newMemFS("journal")
+	p := &Entry{Path: entryPath("journal", testDate), FS: mem, AtomicSave: true, Body: []byte("body\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Rename(testDate.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range mem.files {
+		names = append(names, name)
+	}
+	if want := []string{"journal", entryPath("journal", testDate.AddDate(0, 0, 1))}; len(names) != 2 || mem.files[want[1]] == nil {
+		t.Errorf("files after AtomicSave and Rename = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,73 @@
+package journalentry
+
+import (
+	"bytes"
+	"time"
+)
+
+// Journal is a directory of entries, so that separate journals can be kept side by side.
+type Journal struct {
+	Dir string
+	// FS is the filesystem Dir is in, or OS if nil.
+	FS FS
+}
+
+// Stats summarizes the entries in a Journal.
+type Stats struct {
+	Entries        int
+	Words          int
+	LongestStreak  int
+	RatingCoverage float64
+}
+
+// New returns today's Entry in j, creating it if needed, as New does for j.Dir.
+func (j Journal) New() (*Entry, error) {
+	return NewFS(j.FS, j.Dir)
+}
+
+// Entries loads every Entry in j, as Entries does for j.Dir.
+func (j Journal) Entries() ([]*Entry, error) {
+	return EntriesFS(j.FS, j.Dir)
+}
+
+// Search returns the entries in j whose body contains query, ignoring case, in date order.
+func (j Journal) Search(query string) ([]*Entry, error) {
+	return searchFS(j.FS, j.Dir, query)
+}
+
+// Stats summarizes the entries in j. As with Entries, files that fail to load are left out and their errors returned.
+func (j Journal) Stats() (Stats, error) {
+	streak, _, _, err := j.LongestStreak()
+	if err != nil {
+		return Stats{}, err
+	}
+	entries, err := j.Entries()
+	s := Stats{Entries: len(entries), LongestStreak: streak, RatingCoverage: RatingCoverage(entries)}
+	for _, p := range entries {
+		s.Words += p.WordCount()
+	}
+	return s, err
+}
+
+// LongestStreak is LongestStreak for j.Dir.
+func (j Journal) LongestStreak() (length int, start, end time.Time, err error) {
+	return longestStreakFS(fsOrOS(j.FS), j.Dir)
+}
+
+// Search returns the entries in dir whose body contains query, ignoring case, in date order.
+func Search(dir, query string) ([]*Entry, error) {
+	return searchFS(OS, dir, query)
+}
+
+// searchFS is Search for dir in fsys.
+func searchFS(fsys FS, dir, query string) ([]*Entry, error) {
+	entries, err := EntriesFS(fsys, dir)
+	q := bytes.ToLower([]byte(query))
+	var found []*Entry
+	for _, p := range entries {
+		if bytes.Contains(bytes.ToLower(p.Body), q) {
+			found = append(found, p)
+		}
+	}
+	return found, err
+}
@@ -0,0 +1,42 @@
+package journalentry
+
+import (
+	"testing"
+)
+
+func TestJournalIsolation(t *testing.T) {
+	work, personal := Journal{Dir: t.TempDir()}, Journal{Dir: t.TempDir()}
+	writeEntry(t, work.Dir, testDate, "---\nhighmood: 4\nlowmood: 2\naveragemood: 3\n---\nquarterly planning meeting\n")
+	writeEntry(t, work.Dir, testDate.AddDate(0, 0, 1), "---\n---\nanother meeting\n")
+	writeEntry(t, personal.Dir, testDate, "---\n---\nhiking with friends\n")
+
+	p, err := personal.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Body = []byte("a quiet evening\n")
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, err := work.Entries(); err != nil || len(entries) != 2 {
+		t.Errorf("work has %d entries, %v, want 2", len(entries), err)
+	}
+	if entries, err := personal.Entries(); err != nil || len(entries) != 2 {
+		t.Errorf("personal has %d entries, %v, want 2", len(entries), err)
+	}
+	if found, err := personal.Search("meeting"); err != nil || len(found) != 0 {
+		t.Errorf("personal.Search(meeting) found %v, %v, want none", paths(found), err)
+	}
+	if found, err := work.Search("MEETING"); err != nil || len(found) != 2 {
+		t.Errorf("work.Search(MEETING) found %v, %v, want 2 entries", paths(found), err)
+	}
+
+	s, err := work.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Stats{Entries: 2, Words: 5, LongestStreak: 2, RatingCoverage: 0.5}); s != want {
+		t.Errorf("work.Stats() = %+v, want %+v", s, want)
+	}
+}
@@ -2,51 +2,339 @@ package journalentry
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mikeraimondi/frontmatter/v2"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	entryFormat = "2006-01-02-Journal-Entry-for-Jan-2" + ".md"
-	entryRegex  = `\d{4}-\d{2}-\d{2}-Journal-Entry-for-\D{3}-\d{1,2}` + ".md"
+	// shortEntryFormat is an accepted alternative to entryFormat without the textual month and day.
+	shortEntryFormat = "2006-01-02-Journal-Entry" + ".md"
+	entryRegex       = `^\d{4}-\d{2}-\d{2}-Journal-Entry(-for-\D{3}-\d{1,2})?(-\d+)?` + ".md" + `(\.gz)?$`
+	// suffixRegex matches the suffix distinguishing several entries on the same date, as in "...-Jan-2-3.md".
+	suffixRegex = `(-Journal-Entry(?:-for-\D{3}-\d{1,2})?)-\d+(\.md)$`
 	wordRegex   = `\S+`
+	titleFormat = "Journal Entry for January 2, 2006"
 	ratingRegex = `^[1-5]$`
+	// fmRegex matches frontmatter at the start of a file, up to the first line after the opening one that is just "---".
+	fmRegex = `(?ms)\A\s*---[ \t]*\n.*?^---[ \t]*$`
+	// sectionEnd is the line that ends the answer to a section prompt.
+	sectionEnd = "."
 )
 
+// ErrDirNotWritable is returned when a new entry can't be created because its directory isn't writable.
+var ErrDirNotWritable = errors.New("directory is not writable")
+
+// ErrMetadataOnly is returned when saving an entry loaded by LoadMetadata, which would lose its body.
+var ErrMetadataOnly = errors.New("entry was loaded without its body")
+
+// ErrBodyTooLarge is returned when loading an entry file larger than its MaxBodyBytes.
+var ErrBodyTooLarge = errors.New("entry is too large")
+
+// ErrEntryLocked is returned when saving an entry older than its ReadOnlyAfter threshold.
+var ErrEntryLocked = errors.New("entry is read-only")
+
 // Entry represents a single journal entry.
+//
+// An Entry may be shared between goroutines: its methods guard the body and frontmatter fields,
+// so any number of readers, such as Words and Render, can run alongside one caller of methods that
+// change them, such as SetBody, Load, and Save. Setting fields directly, including Path and the options,
+// is not synchronized and must not race with other use.
 type Entry struct {
 	// TODO move FM attributes to own struct
-	Seconds     uint16
-	LowMood     uint8
-	HighMood    uint8
-	AverageMood uint8
-	Body        []byte    `yaml:"-"`
-	Path        string    `yaml:"-"`
-	ModTime     time.Time `yaml:"-"`
+	// Seconds is the time spent writing. It was once a uint16, whose values still load unchanged.
+	Seconds      uint32
+	LowMood      uint8     `prompt:"Low mood for the day?" scale:"1-5"`
+	HighMood     uint8     `prompt:"High mood for the day?" scale:"1-5"`
+	AverageMood  uint8     `prompt:"Average mood for the day?" scale:"1-5"`
+	Energy       uint8     `yaml:",omitempty"`
+	SleepQuality uint8     `yaml:",omitempty"`
+	Pinned       bool      `yaml:",omitempty"`
+	Private      bool      `yaml:",omitempty"`
+	Attachments  []string  `yaml:",omitempty"`
+	Tags         []string  `yaml:",omitempty"`
+	Headline     string    `yaml:"title,omitempty"`
+	WordGoal     int       `yaml:",omitempty"`
+	Body         []byte    `yaml:"-"`
+	Path         string    `yaml:"-"`
+	ModTime      time.Time `yaml:"-"`
+
+	// NestedMoods makes Save group the moods under a single "mood" key.
+	// Entries are loaded from either form regardless of this setting.
+	NestedMoods bool `yaml:"-"`
+	// AutoSave makes Close save any unsaved changes.
+	AutoSave bool `yaml:"-"`
+	// MoodValidator, if set, replaces ValidateRating for the mood prompts.
+	MoodValidator Validator `yaml:"-"`
+	// AutoAverage makes PromptForMetadata derive the average mood from the high and low instead of asking for it.
+	AutoAverage bool `yaml:"-"`
+	// Separator is the space Save writes between the frontmatter and the body. By default the body is written as it is.
+	Separator Separator `yaml:"-"`
+	// LineEnding is the newline style Save writes. Load always converts newlines to LF in memory.
+	LineEnding LineEnding `yaml:"-"`
+	// TitleFormat, if set, formats the date for Title, e.g. to produce "2 janvier 2024".
+	TitleFormat func(time.Time) string `yaml:"-"`
+	// ReadOnlyAfter, if positive, makes Save refuse to rewrite an entry dated longer ago than this.
+	ReadOnlyAfter time.Duration `yaml:"-"`
+	// AllowAppend lets Append add to an entry that ReadOnlyAfter otherwise locks.
+	AllowAppend bool `yaml:"-"`
+	// ScaleLabels, if set, describes rating values in the rating prompts, as in "(1-5: 1=terrible, 5=great)".
+	ScaleLabels map[uint8]string `yaml:"-"`
+	// CombinedMoods makes PromptForMetadata ask for the moods on a single line, as in "4 2 3" for high, low, and average.
+	// If AutoAverage is also set, only the high and low are asked for.
+	CombinedMoods bool `yaml:"-"`
+	// AtomicSave makes Save write to a temporary file and rename it over p.Path, so a failed save can't leave a partial file.
+	AtomicSave bool `yaml:"-"`
+	// Filename, if set, is the template p.Path was named with, in place of the default format.
+	Filename *FilenameTemplate `yaml:"-"`
+	// Latin1Fallback makes Load decode files that aren't valid UTF-8 as Latin-1 (ISO 8859-1), so Save rewrites them as UTF-8.
+	Latin1Fallback bool `yaml:"-"`
+	// MaxBodyBytes, if positive, makes Load refuse with ErrBodyTooLarge a file larger than this many bytes,
+	// either as stored or once decompressed, rather than reading it into memory unbounded.
+	MaxBodyBytes int64 `yaml:"-"`
+	// Gzip makes Save compress the file. Load sets it when it reads a compressed file.
+	Gzip bool `yaml:"-"`
+	// FS is the filesystem Load and Save use, or OS if nil.
+	FS FS `yaml:"-"`
+	// Sidecar makes Save write the frontmatter to a .yml file beside p.Path, leaving only the body in p.Path.
+	// Load and LoadMetadata read the frontmatter from a sidecar file whenever there is one, and set Sidecar;
+	// an entry without one is loaded from p.Path alone, as usual.
+	Sidecar bool `yaml:"-"`
+	// DiagnosticWriter, if set, receives the rendered contents when Save fails to write them, so they aren't lost.
+	DiagnosticWriter io.Writer `yaml:"-"`
+
+	mu           sync.RWMutex // guards the frontmatter fields, Body, ModTime, and the unexported state below
+	extraPrompts []prompt
+	sections     []section
+	saved        []byte           // the contents last loaded or saved
+	sensitive    bool             // whether a sensitive section was answered, so Save restricts the file to its owner
+	changed      map[string]bool  // the fields set since the last save
+	extra        yaml.MapSlice    // frontmatter keys not belonging to any field
+	seed         map[string]uint8 // moods copied from an earlier entry, by field name, offered as prompt defaults but not saved
+	metadataOnly bool             // whether p was loaded by LoadMetadata
+}
+
+// LineEnding is a newline convention for saved files.
+type LineEnding int
+
+const (
+	// LF ends lines with "\n".
+	LF LineEnding = iota
+	// CRLF ends lines with "\r\n".
+	CRLF
+)
+
+// Separator is the space Save leaves between the frontmatter and the body.
+type Separator int
+
+const (
+	// KeepSeparator writes the body as it is, keeping any blank lines it begins with.
+	KeepSeparator Separator = iota
+	// NoBlankLine starts the body on the line after the closing "---".
+	NoBlankLine
+	// OneBlankLine leaves a single blank line after the closing "---".
+	OneBlankLine
+)
+
+// Moods is the nested frontmatter representation of an Entry's moods.
+type Moods struct {
+	High    uint8 `yaml:"high"`
+	Low     uint8 `yaml:"low"`
+	Average uint8 `yaml:"average"`
+}
+
+// unmarshaler decodes frontmatter into an Entry. yaml.v2 zeroes the value it is given for an empty document,
+// which for an Entry would also wipe its Path and lock, so it is given this wrapper instead.
+type unmarshaler struct{ p *Entry }
+
+func (u unmarshaler) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return u.p.UnmarshalYAML(unmarshal)
+}
+
+// entryFields has the fields of Entry without its YAML methods.
+type entryFields Entry
+
+var flatMoodKeys = map[string]bool{"lowmood": true, "highmood": true, "averagemood": true}
+
+// knownKeys holds every frontmatter key that maps to an Entry field.
+var knownKeys = func() map[string]bool {
+	keys := map[string]bool{"mood": true}
+	t := reflect.TypeOf(entryFields{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// MarshalYAML implements yaml.Marshaler, writing moods in the form selected by p.NestedMoods,
+// followed by any keys read from the file that don't belong to an Entry field.
+func (p *Entry) MarshalYAML() (interface{}, error) {
+	if !p.NestedMoods && len(p.extra) == 0 {
+		return (*entryFields)(p), nil
+	}
+	flat, err := yaml.Marshal((*entryFields)(p))
+	if err != nil {
+		return nil, err
+	}
+	var fields yaml.MapSlice
+	if err := yaml.Unmarshal(flat, &fields); err != nil {
+		return nil, err
+	}
+	var out yaml.MapSlice
+	for _, item := range fields {
+		if !p.NestedMoods || !flatMoodKeys[fmt.Sprint(item.Key)] {
+			out = append(out, item)
+		} else if item.Key == "lowmood" {
+			out = append(out, yaml.MapItem{Key: "mood", Value: Moods{High: p.HighMood, Low: p.LowMood, Average: p.AverageMood}})
+		}
+	}
+	return append(out, p.extra...), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting moods as either flat or nested keys
+// and keeping any other unrecognized keys so that Save writes them back. YAML comments are not kept.
+func (p *Entry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal((*entryFields)(p)); err != nil {
+		return err
+	}
+	var nested struct {
+		Mood *Moods `yaml:"mood"`
+	}
+	if err := unmarshal(&nested); err != nil {
+		return err
+	}
+	if m := nested.Mood; m != nil {
+		p.HighMood, p.LowMood, p.AverageMood = m.High, m.Low, m.Average
+	}
+	var all yaml.MapSlice
+	if err := unmarshal(&all); err != nil {
+		return err
+	}
+	p.extra = nil
+	for _, item := range all {
+		if !knownKeys[fmt.Sprint(item.Key)] {
+			p.extra = append(p.extra, item)
+		}
+	}
+	return nil
+}
+
+// section is a heading whose prose is collected by PromptForMetadata.
+// If trim is set, whitespace surrounding the answer is removed; otherwise it is kept as typed.
+type section struct {
+	heading   string
+	trim      bool
+	sensitive bool
+}
+
+// prompt is a question asked by PromptForMetadata along with the setter that receives the answer.
+type prompt struct {
+	field    string // the name of the Entry field set, or empty for a registered prompt
+	text     string
+	set      func(uint8)
+	validate Validator
+	def      uint8 // the answer assumed for a blank input, if not 0
+	// skip, if set, is called just before asking and reports whether the question is no longer needed.
+	skip func() bool
 }
 
 // New reads the directory named by dir and either returns an existing Entry in that directory, or creates a new one if none exist.
 func New(dir string) (p *Entry, err error) {
-	info, err := os.Stat(dir)
+	return NewWithFilename(dir, nil)
+}
+
+// NewWithFilename is like New, but names today's Entry using t, or the default format if t is nil.
+func NewWithFilename(dir string, t *FilenameTemplate) (p *Entry, err error) {
+	return newEntry(nil, dir, t, false)
+}
+
+// NewSeeded is like New, but a newly created Entry is offered the moods of the most recent earlier entry in dir.
+// PromptForMetadata then asks for each mood with the copied value as the default, kept if the answer is blank.
+// The copied moods are only kept in memory; the Entry is saved without moods until they are confirmed.
+func NewSeeded(dir string) (p *Entry, err error) {
+	return newEntry(nil, dir, nil, true)
+}
+
+// NewFS is like New, but reads and writes dir in fsys. The returned Entry keeps using fsys.
+func NewFS(fsys FS, dir string) (p *Entry, err error) {
+	return newEntry(fsys, dir, nil, false)
+}
+
+// QuickLog sets the moods of today's Entry in dir, creating it if needed, and saves it, leaving the body untouched.
+// Each mood must be from 1 to 5; otherwise nothing is created or changed.
+func QuickLog(dir string, high, low, avg uint8) (*Entry, error) {
+	for _, mood := range []uint8{high, low, avg} {
+		if mood < 1 || mood > 5 {
+			return nil, fmt.Errorf("mood %d is out of range 1-5", mood)
+		}
+	}
+	p, err := New(dir)
+	if err != nil {
+		return p, err
+	}
+	p.SetHighMood(high)
+	p.SetLowMood(low)
+	p.SetAverageMood(avg)
+	return p, p.Save()
+}
+
+func newEntry(fsys FS, dir string, t *FilenameTemplate, seed bool) (p *Entry, err error) {
+	fsys = fsOrOS(fsys)
+	info, err := fsys.Stat(dir)
 	if err != nil {
 		return p, err
 	}
 	if !info.IsDir() {
 		return p, errors.New("must be a directory")
 	}
-	p = &Entry{Path: dir + string(filepath.Separator) + time.Now().Format(entryFormat)}
-	if _, err := os.Stat(p.Path); os.IsNotExist(err) {
+	p = &Entry{Path: entryPath(dir, time.Now()), Filename: t}
+	if fsys != OS {
+		p.FS = fsys
+	}
+	if t != nil {
+		p.Path = filepath.Join(dir, t.Format(time.Now()))
+	}
+	if _, err := fsys.Stat(p.Path); errors.Is(err, fs.ErrNotExist) {
+		if err := checkWritable(fsys, dir); err != nil {
+			return p, err
+		}
 		p.ModTime = time.Now()
+		if seed {
+			if err := p.seedFrom(dir); err != nil {
+				return p, err
+			}
+		}
 		err = p.Save()
 	} else if err == nil {
 		_, err = p.Load()
@@ -54,14 +342,83 @@ func New(dir string) (p *Entry, err error) {
 	return p, err
 }
 
-// Load reads the file named by p.Path and populates the Entry
+// checkWritable returns an error wrapping ErrDirNotWritable if files can't be created in dir.
+func checkWritable(fsys FS, dir string) error {
+	if fsys != OS {
+		name := filepath.Join(dir, ".journalentry-probe")
+		err := writeFile(fsys, name, nil)
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("%w: %v", ErrDirNotWritable, err)
+		} else if err != nil {
+			return err
+		}
+		return fsys.Remove(name)
+	}
+	f, err := os.CreateTemp(dir, ".journalentry-*")
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("%w: %v", ErrDirNotWritable, err)
+	} else if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// seedFrom records the moods of the most recent entry in dir dated before p, if there is one, as prompt defaults.
+func (p *Entry) seedFrom(dir string) error {
+	date, err := p.Date()
+	if err != nil {
+		return err
+	}
+	paths, err := entryPathsFS(p.fs(), dir)
+	if err != nil {
+		return err
+	}
+	for i := len(paths) - 1; i >= 0; i-- {
+		prev := &Entry{Path: paths[i], FS: p.FS}
+		if d, err := prev.Date(); err != nil || !d.Before(date) {
+			continue
+		}
+		if _, err := prev.Load(); err != nil {
+			return err
+		}
+		p.seed = map[string]uint8{"HighMood": prev.HighMood, "LowMood": prev.LowMood, "AverageMood": prev.AverageMood}
+		return nil
+	}
+	return nil
+}
+
+// Import creates an Entry in dir for date whose body is the contents of the file named by src.
+// It refuses to overwrite an existing entry for that date.
+func Import(src string, date time.Time, dir string) (*Entry, error) {
+	body, err := ioutil.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &Entry{Path: entryPath(dir, date), Body: body, ModTime: time.Now()}
+	if _, err := os.Stat(p.Path); err == nil {
+		return nil, fmt.Errorf("cannot import to %s: entry already exists", p.Path)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return p, p.Save()
+}
+
+// entryPath returns the path of the Entry for date in dir.
+func entryPath(dir string, date time.Time) string {
+	return dir + string(filepath.Separator) + date.Format(entryFormat)
+}
+
+// Load reads the file named by p.Path and populates the Entry.
+// If the frontmatter can't be parsed, p.Body is still populated and the parse error is returned.
+// modified reports whether the file changed since p was last loaded; it is false when p has no ModTime yet.
 func (p *Entry) Load() (modified bool, err error) {
-	f, err := os.Open(p.Path)
+	f, err := p.fs().Open(p.Path)
 	if err != nil {
 		return false, err
 	}
 	defer f.Close()
-	data, err := ioutil.ReadAll(f)
+	data, err := readLimited(f, p.MaxBodyBytes)
 	if err != nil {
 		return false, err
 	}
@@ -69,90 +426,1238 @@ func (p *Entry) Load() (modified bool, err error) {
 	if err != nil {
 		return false, err
 	}
-	modified = info.ModTime() != p.ModTime
+	meta, err := p.readSidecar()
+	if err != nil {
+		return false, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	modified = !p.ModTime.IsZero() && !info.ModTime().Equal(p.ModTime)
 	p.ModTime = info.ModTime()
-	p.Body, err = frontmatter.Unmarshal(data, p)
-	return modified, err
+	if meta != nil {
+		p.Sidecar = true
+		return modified, p.parseSidecar(meta, data)
+	}
+	return modified, p.parse(data)
 }
 
-// Save writes the Entry to the file named by p.Path
-func (p *Entry) Save() (err error) {
-	fm, err := frontmatter.Marshal(&p)
+// SidecarPath returns the path of the .yml file that holds p's frontmatter when p.Sidecar is set.
+func (p *Entry) SidecarPath() string {
+	return sidecarPath(p.Path)
+}
+
+// sidecarPath returns the path of the sidecar file for the Entry file at path.
+func sidecarPath(path string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(path, ".gz"), ".md") + ".yml"
+}
+
+// readSidecar returns the contents of p's sidecar file, or nil if it doesn't exist.
+func (p *Entry) readSidecar() ([]byte, error) {
+	data, err := readFile(p.fs(), p.SidecarPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// latin1ToUTF8 decodes data as ISO 8859-1, in which each byte is the code point of the same value.
+func latin1ToUTF8(data []byte) []byte {
+	out := make([]byte, 0, len(data)*2)
+	for _, b := range data {
+		out = utf8.AppendRune(out, rune(b))
+	}
+	return out
+}
+
+// LoadMetadata is like Load, but reads the file named by p.Path only as far as the end of its frontmatter,
+// or not at all if p's frontmatter is in a sidecar file, leaving p.Body empty.
+// An Entry loaded this way can't be saved until it is loaded in full.
+func (p *Entry) LoadMetadata() error {
+	f, err := p.fs().Open(p.Path)
 	if err != nil {
 		return err
 	}
-	var perm os.FileMode = 0666
-	if err = ioutil.WriteFile(p.Path, append(fm, p.Body...), perm); err != nil {
-		fmt.Println("Dump:")
-		fmt.Println(string(fm))
-		fmt.Println(string(p.Body))
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
 	}
+	fm, err := p.readSidecar()
+	if err != nil {
+		return err
+	}
+	sidecar := fm != nil
+	if !sidecar {
+		if fm, err = readFrontmatter(f); err != nil {
+			return err
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clearFrontmatter()
+	if err := yaml.Unmarshal(bytes.ReplaceAll(fm, []byte("\r\n"), []byte("\n")), &unmarshaler{p}); err != nil {
+		return err
+	}
+	if sidecar {
+		p.Sidecar = true
+	}
+	p.Body = nil
+	p.ModTime = info.ModTime()
+	p.metadataOnly = true
+	return nil
+}
+
+// readFrontmatter returns the frontmatter at the start of r, the contents of an Entry file, without its delimiters.
+// It reads no further than the closing delimiter, and returns nil if there is no frontmatter.
+func readFrontmatter(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+	var fm []byte
+	inFrontmatter := false
+	for {
+		line, err := br.ReadString('\n')
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "---" {
+			if inFrontmatter {
+				break
+			}
+			inFrontmatter = true
+		} else if inFrontmatter {
+			fm = append(fm, strings.TrimRight(line, "\r\n")+"\n"...)
+		} else if trimmed != "" {
+			break // no frontmatter
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return fm, nil
+}
+
+// parse populates p from data, the contents of an Entry file.
+func (p *Entry) parse(data []byte) (err error) {
+	p.metadataOnly = false
+	if data, err = p.decode(data); err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		// An empty file, as left by an interrupted sync, is an entry with nothing written yet.
+		p.clearFrontmatter()
+		p.Body = []byte{}
+		p.saved, err = p.render()
+		return err
+	}
+	p.clearFrontmatter()
+	body, err := frontmatter.Unmarshal(data, &unmarshaler{p})
+	if err != nil {
+		body = bodyOf(data)
+	}
+	// The body is a slice of data; copy it so that p doesn't share memory with the caller's buffer.
+	p.Body = append([]byte{}, body...)
+	if err != nil {
+		return err
+	}
+	p.saved, err = p.render()
+	return err
+}
+
+// parseSidecar populates p from meta, the contents of its sidecar file, and data, the contents of p.Path holding only the body.
+func (p *Entry) parseSidecar(meta, data []byte) (err error) {
+	p.metadataOnly = false
+	if data, err = p.decode(data); err != nil {
+		return err
+	}
+	p.clearFrontmatter()
+	p.Body = data
+	if err := yaml.Unmarshal(bytes.ReplaceAll(meta, []byte("\r\n"), []byte("\n")), &unmarshaler{p}); err != nil {
+		return err
+	}
+	p.saved, err = p.render()
 	return err
 }
 
+// decode returns the text of data, the contents of an Entry file, decompressing it and converting it to UTF-8
+// and LF newlines as needed. It sets p.Gzip according to whether data was compressed.
+// The result never shares memory with data.
+func (p *Entry) decode(data []byte) (_ []byte, err error) {
+	if p.Gzip = bytes.HasPrefix(data, gzipMagic); p.Gzip {
+		if data, err = gunzip(data, p.MaxBodyBytes); err != nil {
+			return nil, err
+		}
+	}
+	if p.Latin1Fallback && !utf8.Valid(data) {
+		data = latin1ToUTF8(data)
+	}
+	return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), nil
+}
+
+// bodyOf returns the part of data following the frontmatter, so the text of an entry with unparseable frontmatter isn't lost.
+func bodyOf(data []byte) []byte {
+	loc := regexp.MustCompile(fmRegex).FindIndex(data)
+	if loc == nil {
+		return data
+	}
+	if len(data) <= loc[1]+1 {
+		return []byte{}
+	}
+	return data[loc[1]+1:]
+}
+
+// Save writes the Entry to the file named by p.Path.
+// It returns ErrEntryLocked if p is older than p.ReadOnlyAfter.
+func (p *Entry) Save() (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkLocked(); err != nil {
+		return err
+	}
+	return p.save()
+}
+
+// Append adds text to the end of p.Body and saves p.
+// If p.AllowAppend is set, this is permitted even when Save would return ErrEntryLocked,
+// provided nothing else has changed since p was loaded or saved.
+func (p *Entry) Append(text []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	locked := p.checkLocked()
+	if locked != nil && !p.AllowAppend {
+		return locked
+	}
+	if locked != nil {
+		current, err := p.render()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, p.saved) {
+			return locked
+		}
+	}
+	p.Body = append(p.Body, text...)
+	p.markChanged("Body")
+	return p.save()
+}
+
+// checkLocked returns ErrEntryLocked if p.ReadOnlyAfter is set and p's date is older than it.
+func (p *Entry) checkLocked() error {
+	if p.ReadOnlyAfter <= 0 {
+		return nil
+	}
+	date, err := p.Date()
+	if err != nil {
+		return err
+	}
+	if time.Since(date) > p.ReadOnlyAfter {
+		return ErrEntryLocked
+	}
+	return nil
+}
+
+func (p *Entry) save() (err error) {
+	if p.metadataOnly {
+		return ErrMetadataOnly
+	}
+	data, err := p.render()
+	if err != nil {
+		return err
+	}
+	out := p.withLineEnding(data)
+	if p.Sidecar {
+		out = p.withLineEnding(p.Body)
+	}
+	if p.Gzip || strings.HasSuffix(p.Path, ".gz") {
+		if out, err = gzipped(out); err != nil {
+			return err
+		}
+	}
+	err = p.writeFile(p.Path, out)
+	if err == nil && p.Sidecar {
+		var meta []byte
+		if meta, err = yaml.Marshal(p); err == nil {
+			err = p.writeFile(p.SidecarPath(), p.withLineEnding(meta))
+		}
+	}
+	if err != nil {
+		if p.DiagnosticWriter != nil {
+			fmt.Fprintln(p.DiagnosticWriter, "Dump:")
+			fmt.Fprintln(p.DiagnosticWriter, string(data))
+		}
+		return err
+	}
+	p.saved = data
+	p.changed = nil
+	return nil
+}
+
+// withLineEnding returns data with its newlines converted to p.LineEnding.
+func (p *Entry) withLineEnding(data []byte) []byte {
+	if p.LineEnding != CRLF {
+		return data
+	}
+	return bytes.ReplaceAll(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+}
+
+// writeFile writes data to path in p's FS, atomically if p.AtomicSave is set.
+func (p *Entry) writeFile(path string, data []byte) error {
+	fsys := p.fs()
+	switch {
+	case fsys == OS:
+		var perm os.FileMode = 0666
+		if p.sensitive {
+			perm = 0600
+			// Restrict an existing file before writing, so the sensitive text is never readable by others.
+			if err := os.Chmod(path, perm); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		write := ioutil.WriteFile
+		if p.AtomicSave {
+			write = writeFileAtomic
+		}
+		return write(path, data, perm)
+	case p.AtomicSave:
+		return writeFileRenaming(fsys, path, data)
+	}
+	return writeFile(fsys, path, data)
+}
+
+// fs returns the filesystem p is read from and written to.
+func (p *Entry) fs() FS {
+	return fsOrOS(p.FS)
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it into place,
+// so readers see either the old contents or the new. An existing file's mode is kept, less any permission perm lacks;
+// a new file gets perm without group and other write permission, as under a typical umask.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm &= info.Mode().Perm()
+	} else {
+		perm &^= 0022
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// gzipMagic begins every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func gunzip(data []byte, max int64) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r, max)
+}
+
+// readLimited reads r to the end, or returns ErrBodyTooLarge once more than max bytes are read if max is positive.
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err == nil && int64(len(data)) > max {
+		return nil, ErrBodyTooLarge
+	}
+	return data, err
+}
+
+func gzipped(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Close saves p if AutoSave is set and p has changed since it was last loaded or saved.
+// Closing an unchanged Entry does nothing.
+func (p *Entry) Close() error {
+	if !p.AutoSave {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := p.render()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, p.saved) {
+		return nil
+	}
+	if err := p.checkLocked(); err != nil {
+		return err
+	}
+	return p.save()
+}
+
+// Render returns the contents of p's file: the frontmatter followed by the body.
+// Frontmatter keys are always written in the order of the Entry fields, so saving an unchanged Entry reproduces the same bytes.
+func (p *Entry) Render() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.render()
+}
+
+// render is Render for callers already holding p.mu.
+func (p *Entry) render() ([]byte, error) {
+	fm, err := frontmatter.Marshal(&p)
+	if err != nil {
+		return nil, err
+	}
+	sep, body := p.separated()
+	return append(append(fm, sep...), body...), nil
+}
+
+// separated returns the blank lines to write before p.Body according to p.Separator, and the body to follow them.
+func (p *Entry) separated() (sep string, body []byte) {
+	switch p.Separator {
+	case NoBlankLine:
+		return "", bytes.TrimLeft(p.Body, "\n")
+	case OneBlankLine:
+		return "\n", bytes.TrimLeft(p.Body, "\n")
+	}
+	return "", p.Body
+}
+
+// BodyReader returns a reader over p.Body that doesn't copy it, e.g. for io.Copy to an HTTP response.
+// It reads the body as of the call: changes to p replace the body's bytes rather than modifying them.
+func (p *Entry) BodyReader() io.Reader {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return bytes.NewReader(p.Body)
+}
+
+// RenderReader returns a reader over the same bytes as Render without copying the body.
+// If the frontmatter can't be rendered, reading returns the error.
+func (p *Entry) RenderReader() io.Reader {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	fm, err := frontmatter.Marshal(&p)
+	if err != nil {
+		return errReader{err}
+	}
+	sep, body := p.separated()
+	return io.MultiReader(bytes.NewReader(fm), strings.NewReader(sep), bytes.NewReader(body))
+}
+
+// errReader is an io.Reader that always fails with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// Date returns the date in the name of the file named by p.Path, as parsed by ParseDate,
+// or if p.Filename is set, as parsed by its Parse.
 func (p *Entry) Date() (time.Time, error) {
-	return time.Parse(entryFormat, filepath.Base(p.Path))
+	if p.Filename != nil {
+		return p.Filename.Parse(filepath.Base(p.Path))
+	}
+	return ParseDate(p.Path)
+}
+
+// entryFormats are the file name layouts ParseDate tries, in order.
+var entryFormats = []string{entryFormat, shortEntryFormat}
+
+// ParseDate returns the date in the base name of filename, ignoring any suffix or .gz extension.
+// It tries each supported name format in turn, and if none parses, returns their errors joined.
+func ParseDate(filename string) (time.Time, error) {
+	name := baseName(filename)
+	var errs []error
+	for _, format := range entryFormats {
+		date, err := time.Parse(format, name)
+		if err == nil {
+			return date, nil
+		}
+		errs = append(errs, err)
+	}
+	return time.Time{}, errors.Join(errs...)
+}
+
+// baseName returns the file name of path without any suffix or .gz extension.
+func baseName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".gz")
+	return regexp.MustCompile(suffixRegex).ReplaceAllString(name, "$1$2")
+}
+
+// Title returns a heading for p derived from its date, such as "Journal Entry for January 2, 2006".
+// If p.TitleFormat is set, it formats the date instead. A Headline takes precedence over both.
+func (p *Entry) Title() (string, error) {
+	date, err := p.Date()
+	if err != nil {
+		return "", err
+	}
+	p.mu.RLock()
+	headline := p.Headline
+	p.mu.RUnlock()
+	if headline != "" {
+		return headline, nil
+	}
+	if p.TitleFormat != nil {
+		return p.TitleFormat(date), nil
+	}
+	return date.Format(titleFormat), nil
+}
+
+// Slug returns a lowercase, hyphenated name for p suitable for URLs, such as "2006-01-02-journal-entry".
+// If p has a Headline, it replaces "journal-entry", as in "2006-01-02-a-day-at-the-beach".
+func (p *Entry) Slug() (string, error) {
+	date, err := p.Date()
+	if err != nil {
+		return "", err
+	}
+	name := "journal-entry"
+	if s := slugify(p.Headline); s != "" {
+		name = s
+	}
+	return date.Format("2006-01-02") + "-" + name, nil
+}
+
+// slugify lowercases s and replaces each run of characters other than ASCII letters and digits with a hyphen.
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// Weekday returns the day of the week of p's date.
+func (p *Entry) Weekday() (time.Weekday, error) {
+	date, err := p.Date()
+	if err != nil {
+		return 0, err
+	}
+	return date.Weekday(), nil
+}
+
+// ISOWeek returns the ISO 8601 year and week number of p's date.
+func (p *Entry) ISOWeek() (year, week int, err error) {
+	date, err := p.Date()
+	if err != nil {
+		return 0, 0, err
+	}
+	year, week = date.ISOWeek()
+	return year, week, nil
+}
+
+// withSuffix returns path with its suffix, if any, replaced by n.
+func withSuffix(path string, n int) string {
+	dir, name := filepath.Split(path)
+	gz := strings.HasSuffix(name, ".gz")
+	name = baseName(name)
+	ext := filepath.Ext(name)
+	name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), n, ext)
+	if gz {
+		name += ".gz"
+	}
+	return dir + name
+}
+
+// clone returns a copy of p's frontmatter, Body, Path, and options that shares no memory with p.
+// Registered prompts and sections aren't copied.
+func (p *Entry) clone() *Entry {
+	return &Entry{
+		Seconds:          p.Seconds,
+		LowMood:          p.LowMood,
+		HighMood:         p.HighMood,
+		AverageMood:      p.AverageMood,
+		Energy:           p.Energy,
+		SleepQuality:     p.SleepQuality,
+		Pinned:           p.Pinned,
+		Private:          p.Private,
+		Attachments:      append([]string(nil), p.Attachments...),
+		Tags:             append([]string(nil), p.Tags...),
+		Headline:         p.Headline,
+		WordGoal:         p.WordGoal,
+		Body:             append([]byte(nil), p.Body...),
+		Path:             p.Path,
+		ModTime:          p.ModTime,
+		NestedMoods:      p.NestedMoods,
+		AutoSave:         p.AutoSave,
+		MoodValidator:    p.MoodValidator,
+		AutoAverage:      p.AutoAverage,
+		LineEnding:       p.LineEnding,
+		Separator:        p.Separator,
+		TitleFormat:      p.TitleFormat,
+		ReadOnlyAfter:    p.ReadOnlyAfter,
+		AllowAppend:      p.AllowAppend,
+		AtomicSave:       p.AtomicSave,
+		CombinedMoods:    p.CombinedMoods,
+		ScaleLabels:      p.ScaleLabels,
+		Filename:         p.Filename,
+		Latin1Fallback:   p.Latin1Fallback,
+		MaxBodyBytes:     p.MaxBodyBytes,
+		Gzip:             p.Gzip,
+		FS:               p.FS,
+		Sidecar:          p.Sidecar,
+		DiagnosticWriter: p.DiagnosticWriter,
+		extra:            append(yaml.MapSlice(nil), p.extra...),
+	}
+}
+
+// clearFrontmatter zeroes the fields of p stored in its frontmatter.
+func (p *Entry) clearFrontmatter() {
+	p.Seconds, p.LowMood, p.HighMood, p.AverageMood, p.Energy, p.SleepQuality = 0, 0, 0, 0, 0, 0
+	p.Pinned, p.Private, p.Attachments, p.Tags, p.Headline, p.WordGoal = false, false, nil, nil, "", 0
+	p.extra = nil
+}
+
+// AddAttachment records a reference to the file at path, relative to the directory containing p.
+// It returns an error if no such file exists.
+func (p *Entry) AddAttachment(path string) error {
+	if filepath.IsAbs(path) {
+		return fmt.Errorf("attachment %s must be relative to the entry's directory", path)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(p.Path), path)); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, a := range p.Attachments {
+		if a == path {
+			return nil
+		}
+	}
+	p.Attachments = append(p.Attachments, path)
+	return nil
 }
 
 // Words returns the number of words in p.body
 func (p *Entry) Words() [][]byte {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.words()
+}
+
+// words is Words for callers already holding p.mu.
+func (p *Entry) words() [][]byte {
 	return regexp.MustCompile(wordRegex).FindAll(p.Body, -1)
 }
 
+// WordCount returns the number of words in p.Body.
+func (p *Entry) WordCount() int {
+	return len(p.Words())
+}
+
+// MeetsGoal reports whether p has at least p.WordGoal words. It is always true when no goal is set.
+func (p *Entry) MeetsGoal() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.WordGoal <= 0 || len(p.words()) >= p.WordGoal
+}
+
 // PromptForMetadata prints questions to w and sets the values of p based on values read from reader.
 func (p *Entry) PromptForMetadata(reader io.Reader, w io.Writer) (err error) {
 	r := bufio.NewReader(reader)
-	for prompt, setter := range p.prompts() {
+	next := func() (string, error) { return r.ReadString('\n') }
+	hidden := next
+	if f, ok := reader.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		hidden = func() (string, error) {
+			line, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(w)
+			return string(line) + "\n", err
+		}
+	}
+	return p.promptFor(next, hidden, w)
+}
+
+// PromptForMetadataContext is like PromptForMetadata, but gives up with ctx.Err() once ctx is done, even while waiting for input.
+// A read already in progress when ctx is done is left to finish in the background, and its input is discarded.
+// Because input is read ahead in the background, answers to sensitive sections are echoed as usual.
+func (p *Entry) PromptForMetadataContext(ctx context.Context, reader io.Reader, w io.Writer) error {
+	type result struct {
+		line string
+		err  error
+	}
+	lines := make(chan result)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		r := bufio.NewReader(reader)
 		for {
-			fmt.Fprint(w, prompt)
-			input, err := r.ReadString('\n')
+			line, err := r.ReadString('\n')
+			select {
+			case lines <- result{line, err}:
+			case <-done:
+				return
+			}
 			if err != nil {
-				return err
+				return
 			}
-			input = strings.TrimSpace(input)
-			regex := regexp.MustCompile(ratingRegex)
-			if regex.MatchString(input) {
-				rating, err := strconv.ParseUint(input, 10, 8)
-				if err != nil {
-					return err
-				}
-				setter(uint8(rating))
-				break
+		}
+	}()
+	next := func() (string, error) {
+		select {
+		case res := <-lines:
+			return res.line, res.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return p.promptFor(next, next, w)
+}
+
+// promptFor asks p's questions on w, reading each line of the answers by calling next,
+// or hidden for sensitive sections.
+func (p *Entry) promptFor(next, hidden func() (string, error), w io.Writer) (err error) {
+	p.mu.RLock()
+	prompts := p.prompts()
+	p.mu.RUnlock()
+	if p.CombinedMoods {
+		var moods, rest []prompt
+		for _, pr := range prompts {
+			if flatMoodKeys[strings.ToLower(pr.field)] && !(pr.field == "AverageMood" && p.AutoAverage) {
+				moods = append(moods, pr)
 			} else {
-				fmt.Fprintln(w, "Unrecognized input")
+				rest = append(rest, pr)
 			}
 		}
+		if len(moods) > 0 {
+			if err := p.readMoods(next, w, moods); err != nil {
+				return err
+			}
+		}
+		prompts = rest
+	}
+	for _, pr := range prompts {
+		if pr.skip != nil && pr.skip() {
+			continue
+		}
+		validate := pr.validate
+		if validate == nil {
+			validate = p.MoodValidator
+		}
+		text := p.withLabels(pr.text)
+		if pr.def != 0 {
+			text += fmt.Sprintf("[%d] ", pr.def)
+			validate = withDefault(validate, pr.def)
+		}
+		rating, err := readRating(next, w, text, validate)
+		if err != nil {
+			return err
+		}
+		if rating != 0 {
+			pr.set(rating)
+		}
+	}
+	p.mu.Lock()
+	p.seed = nil
+	p.mu.Unlock()
+	for _, sec := range p.sections {
+		read := next
+		if sec.sensitive {
+			read = hidden
+		}
+		text, err := readSection(read, w, sec.heading)
+		if err != nil {
+			return err
+		}
+		if sec.trim {
+			text = strings.TrimSpace(text)
+		}
+		p.mu.Lock()
+		if sec.sensitive {
+			p.sensitive = true
+		}
+		if len(p.Body) > 0 {
+			p.Body = append(p.Body, '\n')
+		}
+		p.Body = append(p.Body, fmt.Sprintf("## %s\n\n%s\n", sec.heading, text)...)
+		p.markChanged("Body")
+		p.mu.Unlock()
 	}
 	return err
 }
 
-// IsEntry returns true if path refers to a file with an Entry-like name, false otherwise.
+// withLabels returns the prompt text with p.ScaleLabels added in increasing order of value,
+// inside the closing parenthesis of text if it has one.
+func (p *Entry) withLabels(text string) string {
+	if len(p.ScaleLabels) == 0 {
+		return text
+	}
+	values := make([]int, 0, len(p.ScaleLabels))
+	for v := range p.ScaleLabels {
+		values = append(values, int(v))
+	}
+	sort.Ints(values)
+	labels := make([]string, len(values))
+	for i, v := range values {
+		labels[i] = fmt.Sprintf("%d=%s", v, p.ScaleLabels[uint8(v)])
+	}
+	if strings.HasSuffix(text, ") ") {
+		return fmt.Sprintf("%s: %s) ", strings.TrimSuffix(text, ") "), strings.Join(labels, ", "))
+	}
+	return fmt.Sprintf("%s (%s) ", strings.TrimRight(text, " "), strings.Join(labels, ", "))
+}
+
+// readMoods asks for the answers to moods, the mood prompts, on one line of space-separated ratings read with next.
+// The line is asked for again until every rating is accepted. If all of them have defaults, a blank line accepts those.
+func (p *Entry) readMoods(next func() (string, error), w io.Writer, moods []prompt) error {
+	names := make([]string, len(moods))
+	defaults := make([]string, len(moods))
+	hasDefaults := true
+	for i, pr := range moods {
+		names[i] = strings.ToLower(strings.TrimSuffix(pr.field, "Mood"))
+		defaults[i] = strconv.Itoa(int(pr.def))
+		hasDefaults = hasDefaults && pr.def != 0
+	}
+	text := p.withLabels(fmt.Sprintf("Moods for the day? (%s, 1-5 each) ", strings.Join(names, " ")))
+	if hasDefaults {
+		text += fmt.Sprintf("[%s] ", strings.Join(defaults, " "))
+	}
+	for {
+		fmt.Fprint(w, text)
+		input, err := next()
+		if err != nil {
+			return err
+		}
+		answers := strings.Fields(input)
+		if len(answers) == 0 && hasDefaults {
+			answers = defaults
+		}
+		if ratings, ok := p.validateMoods(moods, answers); ok {
+			for i, rating := range ratings {
+				if rating != 0 {
+					moods[i].set(rating)
+				}
+			}
+			return nil
+		}
+		fmt.Fprintln(w, "Unrecognized input")
+	}
+}
+
+// validateMoods returns the ratings for answers to moods, or false if there isn't one accepted answer for each.
+func (p *Entry) validateMoods(moods []prompt, answers []string) ([]uint8, bool) {
+	if len(answers) != len(moods) {
+		return nil, false
+	}
+	ratings := make([]uint8, len(moods))
+	for i, pr := range moods {
+		validate := pr.validate
+		if validate == nil {
+			validate = p.MoodValidator
+		}
+		if validate == nil {
+			validate = ValidateRating
+		}
+		rating, ok := validate(answers[i])
+		if !ok {
+			return nil, false
+		}
+		ratings[i] = rating
+	}
+	return ratings, true
+}
+
+// AddSection registers a heading, such as "What went well?", whose prose PromptForMetadata collects after the ratings.
+// Each answer is appended to p.Body under a "## " heading, with its whitespace preserved.
+func (p *Entry) AddSection(heading string) {
+	p.sections = append(p.sections, section{heading: heading})
+}
+
+// AddTrimmedSection is like AddSection, but removes leading and trailing whitespace from the answer.
+func (p *Entry) AddTrimmedSection(heading string) {
+	p.sections = append(p.sections, section{heading: heading, trim: true})
+}
+
+// AddSensitiveSection is like AddSection, but for private answers. When PromptForMetadata reads from a terminal,
+// the answer is not echoed; other readers, such as pipes, are read as usual. Once one is answered,
+// Save restricts the file to its owner, on the local disk where permissions apply.
+func (p *Entry) AddSensitiveSection(heading string) {
+	p.sections = append(p.sections, section{heading: heading, sensitive: true})
+}
+
+// readSection prints heading to w and reads lines with next until one consisting only of sectionEnd.
+func readSection(next func() (string, error), w io.Writer, heading string) (string, error) {
+	fmt.Fprintf(w, "%s (end with a line containing only %q)\n", heading, sectionEnd)
+	var lines []string
+	for {
+		line, err := next()
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == sectionEnd {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// AddPrompt registers an additional 1-5 rating question for PromptForMetadata.
+// The answer is passed to set, e.g. func(r uint8) { p.Energy = r }.
+func (p *Entry) AddPrompt(text string, set func(uint8)) {
+	p.extraPrompts = append(p.extraPrompts, prompt{text: text, set: set})
+}
+
+// AddValidatedPrompt is like AddPrompt, but accepts the answers approved by validate.
+func (p *Entry) AddValidatedPrompt(text string, set func(uint8), validate Validator) {
+	p.extraPrompts = append(p.extraPrompts, prompt{text: text, set: set, validate: validate})
+}
+
+// Validator checks an answer to a rating prompt, already trimmed of surrounding whitespace.
+// It returns the rating to set and whether the answer is accepted; an accepted rating of 0 leaves the field unset,
+// so a Validator can, for example, let "skip" through. A rejected answer is asked for again.
+type Validator func(input string) (rating uint8, ok bool)
+
+// ValidateRating is the default Validator, accepting only the ratings 1 to 5.
+func ValidateRating(input string) (uint8, bool) {
+	if !regexp.MustCompile(ratingRegex).MatchString(input) {
+		return 0, false
+	}
+	rating, err := strconv.ParseUint(input, 10, 8)
+	return uint8(rating), err == nil
+}
+
+// withDefault returns a Validator that accepts a blank answer as def and otherwise defers to validate,
+// or to ValidateRating if it is nil.
+func withDefault(validate Validator, def uint8) Validator {
+	if validate == nil {
+		validate = ValidateRating
+	}
+	return func(input string) (uint8, bool) {
+		if input == "" {
+			return def, true
+		}
+		return validate(input)
+	}
+}
+
+// readRating prints text to w until an answer accepted by validate, or ValidateRating if it is nil, is read with next.
+func readRating(next func() (string, error), w io.Writer, text string, validate Validator) (uint8, error) {
+	if validate == nil {
+		validate = ValidateRating
+	}
+	for {
+		fmt.Fprint(w, text)
+		input, err := next()
+		if err != nil {
+			return 0, err
+		}
+		if rating, ok := validate(strings.TrimSpace(input)); ok {
+			return rating, nil
+		}
+		fmt.Fprintln(w, "Unrecognized input")
+	}
+}
+
+// IsEntry returns true if the base name of path is an Entry-like name, false otherwise.
+// Temporary files left beside an entry by an interrupted atomic save don't count.
 func IsEntry(path string) bool {
-	return regexp.MustCompile(entryRegex).MatchString(path)
+	return regexp.MustCompile(entryRegex).MatchString(filepath.Base(path))
+}
+
+// Rated reports whether all three of p's moods are set.
+func (p *Entry) Rated() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.HighMood != 0 && p.LowMood != 0 && p.AverageMood != 0
+}
+
+// rating is the value of one of an Entry's 1-5 rating fields. Zero means unrated.
+type rating struct {
+	field string
+	value uint8
 }
 
-func (p *Entry) setLowMood(rating uint8) {
+// ratings returns the values of p's rating fields in field order.
+func (p *Entry) ratings() []rating {
+	return []rating{
+		{"LowMood", p.LowMood},
+		{"HighMood", p.HighMood},
+		{"AverageMood", p.AverageMood},
+		{"Energy", p.Energy},
+		{"SleepQuality", p.SleepQuality},
+	}
+}
+
+// SetLowMood sets p.LowMood and records it as changed.
+func (p *Entry) SetLowMood(rating uint8) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.LowMood = rating
+	p.markChanged("LowMood")
 }
 
-func (p *Entry) setHighMood(rating uint8) {
+// SetHighMood sets p.HighMood and records it as changed.
+func (p *Entry) SetHighMood(rating uint8) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.HighMood = rating
+	p.markChanged("HighMood")
 }
 
-func (p *Entry) setAvgMood(rating uint8) {
+// SetAverageMood sets p.AverageMood and records it as changed.
+func (p *Entry) SetAverageMood(rating uint8) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.AverageMood = rating
+	p.markChanged("AverageMood")
 }
 
-func (p *Entry) prompts() (pr map[string]func(uint8)) {
-	pr = make(map[string]func(uint8))
-	if p.HighMood == 0 {
-		pr["High mood for the day? (1-5) "] = p.setHighMood
+// DeriveAverage sets an unset AverageMood to the midpoint of HighMood and LowMood, rounding halves up
+// (high 5 and low 2 give 4). It does nothing unless both are set, and reports whether it set the average.
+func (p *Entry) DeriveAverage() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.AverageMood != 0 || p.HighMood == 0 || p.LowMood == 0 {
+		return false
 	}
-	if p.LowMood == 0 {
-		pr["Low mood for the day? (1-5) "] = p.setLowMood
+	p.AverageMood = (p.HighMood + p.LowMood + 1) / 2
+	p.markChanged("AverageMood")
+	return true
+}
+
+// SetDuration sets p.Seconds to d in whole seconds, clamped to the range Seconds can hold.
+func (p *Entry) SetDuration(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch secs := d / time.Second; {
+	case secs < 0:
+		p.Seconds = 0
+	case secs > math.MaxUint32:
+		p.Seconds = math.MaxUint32
+	default:
+		p.Seconds = uint32(secs)
 	}
-	if p.AverageMood == 0 {
-		pr["Average mood for the day? (1-5) "] = p.setAvgMood
+	p.markChanged("Seconds")
+}
+
+// SetBody sets p.Body and records it as changed.
+func (p *Entry) SetBody(body []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Body = body
+	p.markChanged("Body")
+}
+
+// ChangedFields returns the sorted names of the fields changed through the setters since p was last saved.
+func (p *Entry) ChangedFields() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	fields := make([]string, 0, len(p.changed))
+	for field := range p.changed {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (p *Entry) markChanged(field string) {
+	if p.changed == nil {
+		p.changed = make(map[string]bool)
+	}
+	p.changed[field] = true
+}
+
+func (p *Entry) prompts() (pr []prompt) {
+	v := reflect.ValueOf(p).Elem()
+	for _, f := range promptFields {
+		rating := uint8(v.Field(f.index).Uint())
+		if rating != 0 {
+			continue
+		}
+		pr = append(pr, prompt{field: f.name, text: f.text, set: p.fieldSetter(f), validate: f.validate, def: p.seed[f.name]})
+		if f.name == "AverageMood" {
+			pr[len(pr)-1].skip = func() bool {
+				return p.AutoAverage && p.DeriveAverage()
+			}
+		}
+	}
+	return append(pr, p.extraPrompts...)
+}
+
+// Rename moves the Entry's file, and its sidecar file if any, to the path for newDate in the same directory and updates p.Path.
+// The new name uses p.Filename if set, and keeps p's same-day suffix and compression.
+// It refuses to overwrite an existing file at the target.
+func (p *Entry) Rename(newDate time.Time) error {
+	newPath := p.pathFor(newDate)
+	if newPath == filepath.Clean(p.Path) {
+		return nil
+	}
+	if err := moveEntry(p.fs(), p.Path, newPath); err != nil {
+		return err
+	}
+	p.Path = newPath
+	return nil
+}
+
+// pathFor returns the path in p's directory that p would have if it were for date,
+// named by p.Filename or the default format, with p's same-day suffix and compression.
+func (p *Entry) pathFor(date time.Time) string {
+	dir := filepath.Dir(p.Path)
+	if p.Filename != nil {
+		path := filepath.Join(dir, p.Filename.Format(date))
+		if strings.HasSuffix(p.Path, ".gz") && !strings.HasSuffix(path, ".gz") {
+			path += ".gz"
+		}
+		return path
+	}
+	path := filepath.Join(dir, date.Format(entryFormat))
+	if n := suffixOf(p.Path); n != 0 {
+		path = withSuffix(path, n)
+	}
+	if strings.HasSuffix(p.Path, ".gz") {
+		path += ".gz"
+	}
+	return path
+}
+
+// promptField is an Entry rating field with a prompt tag, which PromptForMetadata asks for while the field is unset.
+type promptField struct {
+	index    int
+	name     string
+	text     string
+	validate Validator // nil for the default 1-5 scale
+}
+
+// promptFields holds the Entry fields tagged with a prompt, such as `prompt:"Energy today?" scale:"1-10"`, in field order,
+// except that the high mood is asked before the low as it always has been. A field without a scale tag is rated 1-5.
+var promptFields = promptFieldsOf(reflect.TypeOf(entryFields{}))
+
+// promptFieldsOf returns the fields of the struct type t tagged with a prompt, as for promptFields.
+func promptFieldsOf(t reflect.Type) []promptField {
+	var fields []promptField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		text, ok := f.Tag.Lookup("prompt")
+		if !ok {
+			continue
+		}
+		if f.Type.Kind() != reflect.Uint8 {
+			panic(fmt.Sprintf("journalentry: prompt tag on %s, which isn't a uint8", f.Name))
+		}
+		var low, high uint8 = 1, 5
+		if scale, ok := f.Tag.Lookup("scale"); ok {
+			if _, err := fmt.Sscanf(scale, "%d-%d", &low, &high); err != nil || low < 1 || low > high {
+				panic(fmt.Sprintf("journalentry: bad scale %q on %s", scale, f.Name))
+			}
+		}
+		pf := promptField{index: i, name: f.Name, text: fmt.Sprintf("%s (%d-%d) ", text, low, high)}
+		if low != 1 || high != 5 {
+			pf.validate = validateRange(low, high)
+		}
+		fields = append(fields, pf)
+	}
+	for i := 1; i < len(fields); i++ {
+		if fields[i-1].name == "LowMood" && fields[i].name == "HighMood" {
+			fields[i-1], fields[i] = fields[i], fields[i-1]
+		}
+	}
+	return fields
+}
+
+// SetMetadata sets the rating fields PromptForMetadata asks for from values, keyed by field name
+// such as "HighMood" or its frontmatter key "highmood", without prompting. Each value is checked as an answer would be,
+// by MoodValidator or ValidateRating. If any name is unknown or value rejected, nothing is set and every problem is returned.
+func (p *Entry) SetMetadata(values map[string]uint8) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	var sets []func()
+	for _, name := range names {
+		f, ok := promptFieldNamed(name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is not a rating field", name))
+			continue
+		}
+		validate := f.validate
+		if validate == nil {
+			validate = p.MoodValidator
+		}
+		if validate == nil {
+			validate = ValidateRating
+		}
+		rating, ok := validate(strconv.Itoa(int(values[name])))
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %d is not an accepted rating", name, values[name]))
+			continue
+		}
+		set := p.fieldSetter(f)
+		sets = append(sets, func() { set(rating) })
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	for _, set := range sets {
+		set()
+	}
+	return nil
+}
+
+// promptFieldNamed returns the promptField for the field name, ignoring case.
+func promptFieldNamed(name string) (promptField, bool) {
+	for _, f := range promptFields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return promptField{}, false
+}
+
+// validateRange returns a Validator accepting the whole numbers from low to high.
+func validateRange(low, high uint8) Validator {
+	return func(input string) (uint8, bool) {
+		rating, err := strconv.ParseUint(input, 10, 8)
+		if err != nil || uint8(rating) < low || uint8(rating) > high {
+			return 0, false
+		}
+		return uint8(rating), true
+	}
+}
+
+// fieldSetter returns a func setting f on p and recording it as changed, as SetHighMood does for HighMood.
+func (p *Entry) fieldSetter(f promptField) func(uint8) {
+	return func(rating uint8) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		reflect.ValueOf(p).Elem().Field(f.index).SetUint(uint64(rating))
+		p.markChanged(f.name)
 	}
-	return pr
 }
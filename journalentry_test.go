@@ -0,0 +1,1434 @@
+package journalentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDate is the date of test entries whose date doesn't matter.
+var testDate = time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+// writeEntry writes contents to the default-named Entry file in dir for date and returns its path.
+func writeEntry(t *testing.T, dir string, date time.Time, contents string) string {
+	t.Helper()
+	path := entryPath(dir, date)
+	if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readString returns the contents of the file named by path.
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRename(t *testing.T) {
+	dir := t.TempDir()
+	p := &Entry{Path: writeEntry(t, dir, testDate, "---\nhighmood: 4\n---\nbody\n")}
+	newDate := testDate.AddDate(0, 0, 3)
+	if err := p.Rename(newDate); err != nil {
+		t.Fatal(err)
+	}
+	if want := entryPath(dir, newDate); p.Path != want {
+		t.Errorf("Path = %s, want %s", p.Path, want)
+	}
+	if got := readString(t, p.Path); got != "---\nhighmood: 4\n---\nbody\n" {
+		t.Errorf("renamed file = %q", got)
+	}
+	if _, err := os.Stat(entryPath(dir, testDate)); !os.IsNotExist(err) {
+		t.Errorf("old file still exists: %v", err)
+	}
+}
+
+func TestRenameCollision(t *testing.T) {
+	dir := t.TempDir()
+	p := &Entry{Path: writeEntry(t, dir, testDate, "mine\n")}
+	newDate := testDate.AddDate(0, 0, 1)
+	other := writeEntry(t, dir, newDate, "theirs\n")
+	err := p.Rename(newDate)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("Rename onto an existing entry = %v, want an already exists error", err)
+	}
+	if p.Path != entryPath(dir, testDate) {
+		t.Errorf("Path changed to %s", p.Path)
+	}
+	if got := readString(t, other); got != "theirs\n" {
+		t.Errorf("existing entry overwritten with %q", got)
+	}
+	if got := readString(t, p.Path); got != "mine\n" {
+		t.Errorf("renamed entry = %q", got)
+	}
+}
+
+func TestRenameSameDate(t *testing.T) {
+	dir := t.TempDir()
+	p := &Entry{Path: writeEntry(t, dir, testDate, "body\n")}
+	if err := p.Rename(testDate); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p.Path); got != "body\n" {
+		t.Errorf("file = %q", got)
+	}
+}
+
+func TestRenameKeepsSuffixAndCompression(t *testing.T) {
+	dir := t.TempDir()
+	newDate := testDate.AddDate(0, 0, 1)
+	for _, tt := range []struct{ name, want string }{
+		{"2020-01-02-Journal-Entry-for-Jan-2-2.md", "2020-01-03-Journal-Entry-for-Jan-3-2.md"},
+		{"2020-01-02-Journal-Entry-for-Jan-2.md.gz", "2020-01-03-Journal-Entry-for-Jan-3.md.gz"},
+		{"2020-01-02-Journal-Entry.md", "2020-01-03-Journal-Entry-for-Jan-3.md"},
+	} {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		p := &Entry{Path: path}
+		if err := p.Rename(newDate); err != nil {
+			t.Fatal(err)
+		}
+		if got := filepath.Base(p.Path); got != tt.want {
+			t.Errorf("Rename of %s = %s, want %s", tt.name, got, tt.want)
+		}
+		if err := os.Remove(p.Path); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRenameFilenameTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := NewFilenameTemplate("2006-01-02-{weekday}.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, tmpl.Format(testDate))
+	if err := os.WriteFile(path, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	p := &Entry{Path: path, Filename: tmpl}
+	if err := p.Rename(testDate.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := filepath.Base(p.Path), "2020-01-03-friday.md"; got != want {
+		t.Errorf("Path = %s, want %s", got, want)
+	}
+}
+
+func TestPromptForExtraFields(t *testing.T) {
+	p := &Entry{Path: filepath.Join(t.TempDir(), testDate.Format(entryFormat))}
+	p.AddPrompt("Energy? ", func(r uint8) { p.Energy = r })
+	p.AddPrompt("Sleep quality? ", func(r uint8) { p.SleepQuality = r })
+	var out strings.Builder
+	if err := p.PromptForMetadata(strings.NewReader("5\n1\n3\n9\n4\n2\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 5 || p.LowMood != 1 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want 5/1/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	if p.Energy != 4 || p.SleepQuality != 2 {
+		t.Errorf("Energy, SleepQuality = %d, %d, want 4, 2", p.Energy, p.SleepQuality)
+	}
+	if !strings.Contains(out.String(), "Energy? Unrecognized input\nEnergy? Sleep quality? ") {
+		t.Errorf("prompts = %q", out.String())
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Entry{Path: p.Path}
+	if _, err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Energy != 4 || loaded.SleepQuality != 2 {
+		t.Errorf("loaded Energy, SleepQuality = %d, %d, want 4, 2", loaded.Energy, loaded.SleepQuality)
+	}
+}
+
+func TestExtraFieldsOmittedWhenUnset(t *testing.T) {
+	data, err := (&Entry{}).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"energy", "sleepquality"} {
+		if strings.Contains(string(data), key) {
+			t.Errorf("Render of an Entry without %s = %q", key, data)
+		}
+	}
+}
+
+func TestLoadBrokenFrontmatterKeepsBody(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "---\nhighmood: [\n---\nThe text survives.\n")}
+	if _, err := p.Load(); err == nil {
+		t.Fatal("Load of broken frontmatter succeeded")
+	}
+	if got := string(p.Body); got != "The text survives.\n" {
+		t.Errorf("Body = %q", got)
+	}
+}
+
+func TestLoadBrokenFrontmatterBodyWithDashes(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "---\nhighmood: [\n---\nabove the rule ---\n---\nbelow\n")}
+	if _, err := p.Load(); err == nil {
+		t.Fatal("Load of broken frontmatter succeeded")
+	}
+	if got, want := string(p.Body), "above the rule ---\n---\nbelow\n"; got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
+
+func TestNestedMoodsMigratesFlatFile(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "---\nlowmood: 2\nhighmood: 4\naveragemood: 3\n---\nbody\n"), NestedMoods: true}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || p.AverageMood != 3 {
+		t.Fatalf("moods from flat file = %d/%d/%d, want 4/2/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved := readString(t, p.Path)
+	if !strings.Contains(saved, "mood:\n  high: 4\n  low: 2\n  average: 3\n") || strings.Contains(saved, "highmood") {
+		t.Errorf("saved file = %q, want nested moods only", saved)
+	}
+	nested := &Entry{Path: p.Path}
+	if _, err := nested.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if nested.HighMood != 4 || nested.LowMood != 2 || nested.AverageMood != 3 {
+		t.Errorf("moods from nested file = %d/%d/%d, want 4/2/3", nested.HighMood, nested.LowMood, nested.AverageMood)
+	}
+}
+
+func TestFlatMoodsByDefault(t *testing.T) {
+	data, err := (&Entry{HighMood: 4}).Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "highmood: 4\n") || strings.Contains(string(data), "mood:\n") {
+		t.Errorf("Render = %q, want flat moods", data)
+	}
+}
+
+func TestPromptForSections(t *testing.T) {
+	p := &Entry{}
+	p.AddSection("Highlights")
+	p.AddSection("Lowlights")
+	input := "4\n2\n3\nA walk.\nA swim.\n.\nRain.\n.\n"
+	if err := p.PromptForMetadata(strings.NewReader(input), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want 4/2/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	want := "## Highlights\n\nA walk.\nA swim.\n\n## Lowlights\n\nRain.\n"
+	if got := string(p.Body); got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
+
+func TestPromptForSectionWithoutEnd(t *testing.T) {
+	p := &Entry{HighMood: 4, LowMood: 2, AverageMood: 3}
+	p.AddSection("Highlights")
+	if err := p.PromptForMetadata(strings.NewReader("never ended\n"), io.Discard); err != io.EOF {
+		t.Errorf("PromptForMetadata = %v, want io.EOF", err)
+	}
+}
+
+func TestCloseSavesDirtyEntry(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "---\nhighmood: 4\n---\nbody\n"), AutoSave: true}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetLowMood(2)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p.Path); !strings.Contains(got, "lowmood: 2\n") {
+		t.Errorf("file after Close = %q", got)
+	}
+}
+
+func TestCloseSkipsCleanEntry(t *testing.T) {
+	for _, autoSave := range []bool{true, false} {
+		p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "---\nhighmood: 4\n---\nbody\n"), AutoSave: autoSave}
+		if _, err := p.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if !autoSave {
+			p.SetLowMood(2)
+		}
+		// A save would recreate the file.
+		if err := os.Remove(p.Path); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(p.Path); !os.IsNotExist(err) {
+			t.Errorf("Close with AutoSave %v saved the entry", autoSave)
+		}
+	}
+}
+
+func TestSectionWhitespace(t *testing.T) {
+	p := &Entry{}
+	p.AddSection("Kept")
+	p.AddTrimmedSection("Trimmed")
+	input := "  4 \n\t2\n3  \n  indented  and  spaced  \n.\n   padded   \n.\n"
+	if err := p.PromptForMetadata(strings.NewReader(input), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want numeric answers trimmed to 4/2/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	want := "## Kept\n\n  indented  and  spaced  \n\n## Trimmed\n\npadded\n"
+	if got := string(p.Body); got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
+
+func TestPinnedRoundTrip(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate), Pinned: true}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p.Path); !strings.Contains(got, "pinned: true\n") {
+		t.Errorf("saved file = %q", got)
+	}
+	loaded := &Entry{Path: p.Path}
+	if _, err := loaded.Load(); err != nil || !loaded.Pinned {
+		t.Errorf("loaded Pinned = %v, %v", loaded.Pinned, err)
+	}
+	data, err := (&Entry{}).Render()
+	if err != nil || strings.Contains(string(data), "pinned") {
+		t.Errorf("Render of an unpinned Entry = %q, %v", data, err)
+	}
+}
+
+func TestRenderKeyOrderStable(t *testing.T) {
+	p := &Entry{
+		Path: entryPath(t.TempDir(), testDate), Seconds: 60, LowMood: 2, HighMood: 4, AverageMood: 3,
+		Tags: []string{"b", "a"}, Headline: "Title", Body: []byte("body\n"),
+	}
+	first, err := p.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\nseconds: 60\nlowmood: 2\nhighmood: 4\naveragemood: 3\ntags:\n- b\n- a\ntitle: Title\n---\nbody\n"
+	if string(first) != want {
+		t.Errorf("Render = %q, want keys in field order %q", first, want)
+	}
+	for i := 0; i < 3; i++ {
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+		p = &Entry{Path: p.Path}
+		if _, err := p.Load(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readString(t, p.Path); got != string(first) {
+		t.Errorf("after repeated saves, file = %q, want %q", got, first)
+	}
+}
+
+func TestImport(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(src, []byte("Written elsewhere.\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	p, err := Import(src, testDate, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Path != entryPath(dir, testDate) {
+		t.Errorf("Path = %s, want %s", p.Path, entryPath(dir, testDate))
+	}
+	loaded := &Entry{Path: p.Path}
+	if _, err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if string(loaded.Body) != "Written elsewhere.\n" {
+		t.Errorf("imported Body = %q", loaded.Body)
+	}
+	if date, err := loaded.Date(); err != nil || !date.Equal(testDate) {
+		t.Errorf("imported Date = %v, %v", date, err)
+	}
+	if _, err := Import(src, testDate, dir); err == nil {
+		t.Error("Import over an existing entry succeeded")
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate)}
+	if got := p.ChangedFields(); len(got) != 0 {
+		t.Errorf("ChangedFields of a new Entry = %v", got)
+	}
+	p.SetHighMood(4)
+	p.SetBody([]byte("text\n"))
+	p.SetHighMood(5)
+	if got, want := p.ChangedFields(), []string{"Body", "HighMood"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFields = %v, want %v", got, want)
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.ChangedFields(); len(got) != 0 {
+		t.Errorf("ChangedFields after Save = %v", got)
+	}
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	path := entryPath(t.TempDir(), testDate) + ".gz"
+	p := &Entry{Path: path, HighMood: 4, Body: []byte("compressed\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if raw := readString(t, path); !strings.HasPrefix(raw, string(gzipMagic)) {
+		t.Fatalf("saved file isn't gzipped: %q", raw)
+	}
+	loaded := &Entry{Path: path}
+	if _, err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Gzip || loaded.HighMood != 4 || string(loaded.Body) != "compressed\n" {
+		t.Errorf("loaded Gzip %v, HighMood %d, Body %q", loaded.Gzip, loaded.HighMood, loaded.Body)
+	}
+	if !IsEntry(path) {
+		t.Errorf("IsEntry(%s) = false", path)
+	}
+}
+
+func TestGzipOption(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate), Gzip: true, Body: []byte("body\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Entry{Path: p.Path}
+	if _, err := loaded.Load(); err != nil || string(loaded.Body) != "body\n" || !loaded.Gzip {
+		t.Errorf("loaded Body %q, Gzip %v, err %v", loaded.Body, loaded.Gzip, err)
+	}
+}
+
+func TestPlainFileUnchanged(t *testing.T) {
+	contents := "---\nseconds: 0\nlowmood: 0\nhighmood: 4\naveragemood: 0\n---\nplain\n"
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, contents)}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Gzip {
+		t.Error("Load of a plain file set Gzip")
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p.Path); got != contents {
+		t.Errorf("resaved plain file = %q, want %q", got, contents)
+	}
+}
+
+func TestDeriveAverage(t *testing.T) {
+	for _, tt := range []struct {
+		high, low, avg uint8
+		want           uint8
+		derived        bool
+	}{
+		{5, 2, 0, 4, true},
+		{4, 2, 0, 3, true},
+		{1, 1, 0, 1, true},
+		{5, 2, 1, 1, false},
+		{5, 0, 0, 0, false},
+	} {
+		p := &Entry{HighMood: tt.high, LowMood: tt.low, AverageMood: tt.avg}
+		if derived := p.DeriveAverage(); derived != tt.derived || p.AverageMood != tt.want {
+			t.Errorf("DeriveAverage with %d/%d/%d = %v, average %d, want %v, %d", tt.high, tt.low, tt.avg, derived, p.AverageMood, tt.derived, tt.want)
+		}
+	}
+}
+
+func TestAutoAverageSkipsPrompt(t *testing.T) {
+	p := &Entry{AutoAverage: true}
+	var out strings.Builder
+	if err := p.PromptForMetadata(strings.NewReader("5\n2\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if p.LowMood != 2 || p.HighMood != 5 || p.AverageMood != 4 {
+		t.Errorf("moods = %d/%d/%d, want 5/2/4", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	if strings.Contains(out.String(), "Average") {
+		t.Errorf("asked for the average: %q", out.String())
+	}
+}
+
+func TestWeekdayAndISOWeek(t *testing.T) {
+	p := &Entry{Path: entryPath("journal", time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC))}
+	if wd, err := p.Weekday(); err != nil || wd != time.Sunday {
+		t.Errorf("Weekday = %v, %v, want Sunday", wd, err)
+	}
+	if year, week, err := p.ISOWeek(); err != nil || year != 2020 || week != 53 {
+		t.Errorf("ISOWeek = %d-W%d, %v, want 2020-W53", year, week, err)
+	}
+	bad := &Entry{Path: "journal/notes.md"}
+	if _, err := bad.Weekday(); err == nil {
+		t.Error("Weekday of a malformed name succeeded")
+	}
+	if _, _, err := bad.ISOWeek(); err == nil {
+		t.Error("ISOWeek of a malformed name succeeded")
+	}
+}
+
+func TestLineEndingRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		ending LineEnding
+		want   string
+	}{
+		{LF, "---\nseconds: 0\nlowmood: 0\nhighmood: 4\naveragemood: 0\n---\none\ntwo\n"},
+		{CRLF, "---\r\nseconds: 0\r\nlowmood: 0\r\nhighmood: 4\r\naveragemood: 0\r\n---\r\none\r\ntwo\r\n"},
+	} {
+		p := &Entry{Path: entryPath(t.TempDir(), testDate), HighMood: 4, Body: []byte("one\ntwo\n"), LineEnding: tt.ending}
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if got := readString(t, p.Path); got != tt.want {
+			t.Errorf("saved with LineEnding %d = %q, want %q", tt.ending, got, tt.want)
+		}
+		loaded := &Entry{Path: p.Path}
+		if _, err := loaded.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if loaded.HighMood != 4 || string(loaded.Body) != "one\ntwo\n" {
+			t.Errorf("loaded with LineEnding %d: HighMood %d, Body %q", tt.ending, loaded.HighMood, loaded.Body)
+		}
+	}
+}
+
+func TestAddAttachment(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "photo.jpg"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	p := &Entry{Path: entryPath(dir, testDate)}
+	if err := p.AddAttachment("photo.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddAttachment("photo.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddAttachment("missing.jpg"); err == nil {
+		t.Error("AddAttachment of a missing file succeeded")
+	}
+	if err := p.AddAttachment(filepath.Join(dir, "photo.jpg")); err == nil {
+		t.Error("AddAttachment of an absolute path succeeded")
+	}
+	if !reflect.DeepEqual(p.Attachments, []string{"photo.jpg"}) {
+		t.Errorf("Attachments = %v, want [photo.jpg]", p.Attachments)
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p.Path); !strings.Contains(got, "attachments:\n- photo.jpg\n") {
+		t.Errorf("saved file = %q", got)
+	}
+}
+
+func TestAttachmentsAbsent(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "---\nhighmood: 3\n---\n")}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Attachments != nil {
+		t.Errorf("Attachments = %#v, want nil", p.Attachments)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	p := &Entry{Path: entryPath("journal", time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC))}
+	if got, err := p.Title(); err != nil || got != "Journal Entry for January 2, 2024" {
+		t.Errorf("Title = %q, %v", got, err)
+	}
+	months := []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+	p.TitleFormat = func(date time.Time) string {
+		return fmt.Sprintf("%d %s %d", date.Day(), months[date.Month()-1], date.Year())
+	}
+	if got, err := p.Title(); err != nil || got != "2 janvier 2024" {
+		t.Errorf("Title with a French TitleFormat = %q, %v", got, err)
+	}
+}
+
+func TestShortFilenameForm(t *testing.T) {
+	for _, name := range []string{
+		"2024-01-02-Journal-Entry-for-Jan-2.md",
+		"2024-01-02-Journal-Entry.md",
+		"2024-01-02-Journal-Entry-3.md",
+		"2024-01-02-Journal-Entry.md.gz",
+	} {
+		if !IsEntry(name) {
+			t.Errorf("IsEntry(%q) = false", name)
+		}
+		date, err := (&Entry{Path: filepath.Join("journal", name)}).Date()
+		if want := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC); err != nil || !date.Equal(want) {
+			t.Errorf("Date of %s = %v, %v, want %v", name, date, err, want)
+		}
+	}
+	if IsEntry("2024-01-02-Diary.md") {
+		t.Error("IsEntry(2024-01-02-Diary.md) = true")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	p := &Entry{Path: entryPath("journal", time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC))}
+	if got, err := p.Slug(); err != nil || got != "2024-01-02-journal-entry" {
+		t.Errorf("Slug = %q, %v", got, err)
+	}
+	p.Headline = "A Day at the Beach!"
+	if got, err := p.Slug(); err != nil || got != "2024-01-02-a-day-at-the-beach" {
+		t.Errorf("Slug with a headline = %q, %v", got, err)
+	}
+	p.Headline = "!!!"
+	if got, err := p.Slug(); err != nil || got != "2024-01-02-journal-entry" {
+		t.Errorf("Slug with an unsluggable headline = %q, %v", got, err)
+	}
+}
+
+func TestLoadZeroByteFile(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "")}
+	if _, err := p.Load(); err != nil {
+		t.Fatalf("Load of a zero-byte file = %v", err)
+	}
+	if len(p.Body) != 0 || p.Rated() || p.HighMood != 0 {
+		t.Errorf("zero-byte entry has Body %q, moods %d/%d/%d", p.Body, p.HighMood, p.LowMood, p.AverageMood)
+	}
+}
+
+func TestMeetsGoal(t *testing.T) {
+	for _, tt := range []struct {
+		body string
+		goal int
+		want bool
+	}{
+		{"three words here", 3, true},
+		{"two words", 3, false},
+		{"", 0, true},
+	} {
+		p := &Entry{Body: []byte(tt.body), WordGoal: tt.goal}
+		if got := p.MeetsGoal(); got != tt.want {
+			t.Errorf("MeetsGoal of %q with goal %d = %v, want %v", tt.body, tt.goal, got, tt.want)
+		}
+	}
+}
+
+func TestBodyAndRenderReaders(t *testing.T) {
+	p := &Entry{HighMood: 4, Body: []byte("streamed\nbody\n"), Separator: OneBlankLine}
+	body, err := io.ReadAll(p.BodyReader())
+	if err != nil || string(body) != string(p.Body) {
+		t.Errorf("BodyReader = %q, %v, want %q", body, err, p.Body)
+	}
+	rendered, err := p.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := io.ReadAll(p.RenderReader())
+	if err != nil || string(streamed) != string(rendered) {
+		t.Errorf("RenderReader = %q, %v, want %q", streamed, err, rendered)
+	}
+}
+
+func TestReadOnlyAfter(t *testing.T) {
+	dir := t.TempDir()
+	old := &Entry{Path: writeEntry(t, dir, time.Now().AddDate(0, 0, -30), "old\n"), ReadOnlyAfter: 7 * 24 * time.Hour}
+	if _, err := old.Load(); err != nil {
+		t.Fatal(err)
+	}
+	old.SetBody([]byte("rewritten\n"))
+	if err := old.Save(); !errors.Is(err, ErrEntryLocked) {
+		t.Errorf("Save of an old entry = %v, want ErrEntryLocked", err)
+	}
+	if got := readString(t, old.Path); got != "old\n" {
+		t.Errorf("locked file = %q", got)
+	}
+	recent := &Entry{Path: entryPath(dir, time.Now()), ReadOnlyAfter: 7 * 24 * time.Hour, Body: []byte("new\n")}
+	if err := recent.Save(); err != nil {
+		t.Errorf("Save of a recent entry = %v", err)
+	}
+}
+
+func TestAppendToLockedEntry(t *testing.T) {
+	path := writeEntry(t, t.TempDir(), time.Now().AddDate(0, 0, -30), "---\nhighmood: 3\n---\nold\n")
+	p := &Entry{Path: path, ReadOnlyAfter: 24 * time.Hour}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Append([]byte("more\n")); !errors.Is(err, ErrEntryLocked) {
+		t.Errorf("Append without AllowAppend = %v, want ErrEntryLocked", err)
+	}
+	p = &Entry{Path: path, ReadOnlyAfter: 24 * time.Hour, AllowAppend: true}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Append([]byte("more\n")); err != nil {
+		t.Fatalf("Append with AllowAppend = %v", err)
+	}
+	if got := readString(t, path); !strings.HasSuffix(got, "old\nmore\n") {
+		t.Errorf("file after Append = %q", got)
+	}
+	p.SetHighMood(5)
+	if err := p.Append([]byte("again\n")); !errors.Is(err, ErrEntryLocked) {
+		t.Errorf("Append after another change = %v, want ErrEntryLocked", err)
+	}
+}
+
+// skippable accepts "skip", leaving the field unset, as well as the usual ratings.
+func skippable(input string) (uint8, bool) {
+	if input == "skip" {
+		return 0, true
+	}
+	return ValidateRating(input)
+}
+
+func TestValidatorSkip(t *testing.T) {
+	p := &Entry{HighMood: 4, LowMood: 2, AverageMood: 3}
+	energy := uint8(9)
+	p.AddValidatedPrompt("Energy? ", func(r uint8) { energy = r }, skippable)
+	var out strings.Builder
+	if err := p.PromptForMetadata(strings.NewReader("maybe\nskip\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if energy != 9 {
+		t.Errorf("skipped prompt set %d", energy)
+	}
+	if got := strings.Count(out.String(), "Energy? "); got != 2 {
+		t.Errorf("asked %d times, want a rejected answer asked again: %q", got, out.String())
+	}
+}
+
+func TestMoodValidator(t *testing.T) {
+	p := &Entry{MoodValidator: skippable}
+	if err := p.PromptForMetadata(strings.NewReader("skip\n4\n3\n"), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 0 || p.LowMood != 4 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want 0/4/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+}
+
+func TestUnknownKeysSurviveSave(t *testing.T) {
+	contents := "---\nhighmood: 4\nweather: sunny\nplaces:\n- park\n- cafe\n---\nbody\n"
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, contents)}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	p.SetLowMood(2)
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	saved := readString(t, p.Path)
+	for _, want := range []string{"lowmood: 2\n", "highmood: 4\n", "weather: sunny\n", "places:\n- park\n- cafe\n"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved file = %q, missing %q", saved, want)
+		}
+	}
+	p.NestedMoods = true
+	data, err := p.Render()
+	if err != nil || !strings.Contains(string(data), "weather: sunny\n") {
+		t.Errorf("Render with NestedMoods = %q, %v", data, err)
+	}
+}
+
+func TestPromptForMetadataContextCancel(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- (&Entry{}).PromptForMetadataContext(ctx, r, io.Discard) }()
+	if _, err := w.Write([]byte("4\n")); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("PromptForMetadataContext = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("PromptForMetadataContext didn't return after cancellation")
+	}
+}
+
+func TestPromptForMetadataContext(t *testing.T) {
+	p := &Entry{}
+	if err := p.PromptForMetadataContext(context.Background(), strings.NewReader("4\n2\n3\n"), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want 4/2/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+}
+
+func TestNewSeeded(t *testing.T) {
+	dir := t.TempDir()
+	writeEntry(t, dir, time.Now().AddDate(0, 0, -3), "---\nhighmood: 1\nlowmood: 1\naveragemood: 1\n---\n")
+	writeEntry(t, dir, time.Now().AddDate(0, 0, -1), "---\nhighmood: 5\nlowmood: 2\naveragemood: 4\n---\n")
+	p, err := NewSeeded(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Rated() {
+		t.Errorf("seeded moods set on the Entry: %d/%d/%d", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	if got := readString(t, p.Path); !strings.Contains(got, "highmood: 0\n") {
+		t.Errorf("saved new entry = %q, want no seeded moods", got)
+	}
+	var out strings.Builder
+	if err := p.PromptForMetadata(strings.NewReader("\n3\n\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 5 || p.LowMood != 3 || p.AverageMood != 4 {
+		t.Errorf("moods = %d/%d/%d, want 5/3/4", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	if !strings.Contains(out.String(), "[5] ") || !strings.Contains(out.String(), "[2] ") {
+		t.Errorf("prompts = %q, want seeded defaults", out.String())
+	}
+}
+
+func TestNewSeededSameDay(t *testing.T) {
+	dir := t.TempDir()
+	first := writeEntry(t, dir, time.Now().AddDate(0, 0, -1), "---\nhighmood: 1\nlowmood: 1\naveragemood: 1\n---\n")
+	for n, mood := range map[int]string{2: "2", 10: "5"} {
+		if err := os.WriteFile(withSuffix(first, n), []byte("---\nhighmood: "+mood+"\n---\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := NewSeeded(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.seed["HighMood"]; got != 5 {
+		t.Errorf("seeded HighMood = %d, want 5 from the last file of the day", got)
+	}
+}
+
+func TestNewSeededFirstEntry(t *testing.T) {
+	p, err := NewSeeded(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := p.PromptForMetadata(strings.NewReader("\n4\n2\n3\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want 4/2/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	if strings.Contains(out.String(), "[") {
+		t.Errorf("prompts = %q, want no defaults", out.String())
+	}
+}
+
+func TestSecondsBeyondUint16(t *testing.T) {
+	for _, secs := range []uint32{65535, 65536, 100000} {
+		p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, fmt.Sprintf("---\nseconds: %d\n---\n", secs))}
+		if _, err := p.Load(); err != nil || p.Seconds != secs {
+			t.Errorf("loaded Seconds %d = %d, %v", secs, p.Seconds, err)
+		}
+	}
+}
+
+func TestSetDuration(t *testing.T) {
+	for _, tt := range []struct {
+		d    time.Duration
+		want uint32
+	}{
+		{90 * time.Second, 90},
+		{1500 * time.Millisecond, 1},
+		{65536 * time.Second, 65536},
+		{-time.Second, 0},
+		{(math.MaxUint32 + 1) * time.Second, math.MaxUint32},
+	} {
+		p := &Entry{}
+		p.SetDuration(tt.d)
+		if p.Seconds != tt.want {
+			t.Errorf("SetDuration(%v) set Seconds %d, want %d", tt.d, p.Seconds, tt.want)
+		}
+	}
+}
+
+func TestLoadModified(t *testing.T) {
+	p := &Entry{Path: writeEntry(t, t.TempDir(), testDate, "first\n")}
+	if modified, err := p.Load(); err != nil || modified {
+		t.Errorf("first Load = %v, %v, want not modified", modified, err)
+	}
+	if modified, err := p.Load(); err != nil || modified {
+		t.Errorf("Load of an unchanged file = %v, %v, want not modified", modified, err)
+	}
+	if err := os.WriteFile(p.Path, []byte("second\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	later := p.ModTime.Add(time.Minute)
+	if err := os.Chtimes(p.Path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if modified, err := p.Load(); err != nil || !modified {
+		t.Errorf("Load of a changed file = %v, %v, want modified", modified, err)
+	}
+	if string(p.Body) != "second\n" {
+		t.Errorf("Body = %q", p.Body)
+	}
+}
+
+func TestPrivateRoundTrip(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate), Private: true}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Entry{Path: p.Path}
+	if _, err := loaded.Load(); err != nil || !loaded.Private {
+		t.Errorf("loaded Private = %v, %v", loaded.Private, err)
+	}
+	if data, _ := (&Entry{}).Render(); strings.Contains(string(data), "private") {
+		t.Errorf("Render of a public Entry = %q", data)
+	}
+}
+
+func TestParseCopiesBody(t *testing.T) {
+	for _, contents := range []string{"---\nhighmood: 4\n---\nbody text\n", "---\nhighmood: [\n---\nbody text\n", "body text\n"} {
+		data := []byte(contents)
+		p := &Entry{}
+		p.parse(data)
+		for i := range data {
+			data[i] = 'x'
+		}
+		if string(p.Body) != "body text\n" {
+			t.Errorf("Body after overwriting the buffer of %q = %q", contents, p.Body)
+		}
+	}
+}
+
+// readOnlyFS is the OS filesystem with file creation denied.
+type readOnlyFS struct{ FS }
+
+func (readOnlyFS) Create(name string) (io.WriteCloser, error) {
+	return nil, &os.PathError{Op: "create", Path: name, Err: os.ErrPermission}
+}
+
+func TestNewReadOnlyDir(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFS(readOnlyFS{OS}, dir); !errors.Is(err, ErrDirNotWritable) {
+		t.Errorf("NewFS with Create denied returned %v, want ErrDirNotWritable", err)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+	if _, err := New(dir); !errors.Is(err, ErrDirNotWritable) {
+		t.Errorf("New in a read-only directory returned %v, want ErrDirNotWritable", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("read-only directory has %d files, want none", len(entries))
+	}
+}
+
+func TestLoadEmptyFrontmatter(t *testing.T) {
+	path := writeEntry(t, t.TempDir(), testDate, "---\n---\nbody\n")
+	p := &Entry{Path: path, HighMood: 4}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Path != path || p.HighMood != 0 || string(p.Body) != "body\n" {
+		t.Errorf("Load of empty frontmatter = %q, HighMood %d, Body %q", p.Path, p.HighMood, p.Body)
+	}
+	if err := p.LoadMetadata(); err != nil || p.Path != path {
+		t.Errorf("LoadMetadata of empty frontmatter = %v, Path %q", err, p.Path)
+	}
+}
+
+func TestLoadMetadata(t *testing.T) {
+	path := writeEntry(t, t.TempDir(), testDate, "---\nhighmood: 4\nlowmood: 2\ntags: [work]\n---\nbody\n")
+	p := &Entry{Path: path}
+	if err := p.LoadMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || !reflect.DeepEqual(p.Tags, []string{"work"}) {
+		t.Errorf("LoadMetadata = HighMood %d, LowMood %d, Tags %v", p.HighMood, p.LowMood, p.Tags)
+	}
+	if len(p.Body) != 0 {
+		t.Errorf("LoadMetadata Body = %q, want empty", p.Body)
+	}
+	if err := p.Save(); !errors.Is(err, ErrMetadataOnly) {
+		t.Errorf("Save after LoadMetadata = %v, want ErrMetadataOnly", err)
+	}
+	if got := readString(t, path); !strings.HasSuffix(got, "body\n") {
+		t.Errorf("file after refused Save = %q", got)
+	}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Save(); err != nil {
+		t.Errorf("Save after Load = %v", err)
+	}
+}
+
+// failingReader returns err from every Read.
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestReadFrontmatterStopsAtDelimiter(t *testing.T) {
+	errBody := errors.New("read past the frontmatter")
+	r := io.MultiReader(strings.NewReader("---\nhighmood: 4\n---\n"), failingReader{errBody})
+	fm, err := readFrontmatter(r)
+	if err != nil || string(fm) != "highmood: 4\n" {
+		t.Errorf("readFrontmatter = %q, %v, want %q", fm, err, "highmood: 4\n")
+	}
+}
+
+func TestLatin1Fallback(t *testing.T) {
+	// "café" and "naïve" in Latin-1: é is 0xE9 and ï is 0xEF.
+	path := writeEntry(t, t.TempDir(), testDate, "---\ntitle: caf\xe9\n---\nna\xefve\n")
+	p := &Entry{Path: path, Latin1Fallback: true}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Headline != "café" || string(p.Body) != "naïve\n" {
+		t.Errorf("Load of Latin-1 = Headline %q, Body %q, want café, naïve", p.Headline, p.Body)
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, path); !strings.Contains(got, "café") || !strings.HasSuffix(got, "naïve\n") {
+		t.Errorf("saved file = %q, want UTF-8", got)
+	}
+
+	utf := writeEntry(t, t.TempDir(), testDate, "---\n---\nnaïve\n")
+	p = &Entry{Path: utf, Latin1Fallback: true}
+	if _, err := p.Load(); err != nil || string(p.Body) != "naïve\n" {
+		t.Errorf("Load of UTF-8 with Latin1Fallback = %q, %v", p.Body, err)
+	}
+}
+
+func TestSensitiveSection(t *testing.T) {
+	for _, atomic := range []bool{false, true} {
+		path := writeEntry(t, t.TempDir(), testDate, "---\n---\n")
+		if err := os.Chmod(path, 0644); err != nil {
+			t.Fatal(err)
+		}
+		p := &Entry{Path: path, AtomicSave: atomic}
+		p.AddSensitiveSection("Private")
+		// A strings.Reader isn't a terminal, so the answer is read as usual.
+		if err := p.PromptForMetadata(strings.NewReader("4\n2\n3\nsecret\n.\n"), io.Discard); err != nil {
+			t.Fatal(err)
+		}
+		if want := "## Private\n\nsecret\n"; string(p.Body) != want {
+			t.Errorf("Body = %q, want %q", p.Body, want)
+		}
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0600 {
+			t.Errorf("mode after Save with AtomicSave %t = %v, want 0600", atomic, mode)
+		}
+	}
+}
+
+func TestSaveFailureDiagnostics(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing", "entry.md")
+
+	var diag strings.Builder
+	p := &Entry{Path: missing, HighMood: 4, Body: []byte("lost words\n"), DiagnosticWriter: &diag}
+	if err := p.Save(); err == nil {
+		t.Fatal("Save into a missing directory succeeded")
+	}
+	if got := diag.String(); !strings.Contains(got, "highmood: 4\n") || !strings.Contains(got, "lost words\n") {
+		t.Errorf("DiagnosticWriter got %q, want the rendered entry", got)
+	}
+
+	// With no DiagnosticWriter, nothing is written to stdout.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	p = &Entry{Path: missing, Body: []byte("lost words\n")}
+	saveErr := p.Save()
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if saveErr == nil {
+		t.Fatal("Save into a missing directory succeeded")
+	}
+	if len(out) != 0 {
+		t.Errorf("Save failure wrote %q to stdout", out)
+	}
+}
+
+func TestQuickLog(t *testing.T) {
+	dir := t.TempDir()
+	p, err := QuickLog(dir, 5, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Body) != 0 {
+		t.Errorf("Body of a new mood-only entry = %q, want empty", p.Body)
+	}
+	loaded := &Entry{Path: p.Path}
+	if _, err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HighMood != 5 || loaded.LowMood != 2 || loaded.AverageMood != 4 {
+		t.Errorf("saved moods = %d/%d/%d, want 5/2/4", loaded.HighMood, loaded.LowMood, loaded.AverageMood)
+	}
+
+	loaded.Body = []byte("kept\n")
+	if err := loaded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = QuickLog(dir, 3, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p.Path); !strings.Contains(got, "averagemood: 2\n") || !strings.HasSuffix(got, "\nkept\n") {
+		t.Errorf("file after updating moods = %q", got)
+	}
+}
+
+func TestQuickLogOutOfRange(t *testing.T) {
+	dir := t.TempDir()
+	for _, moods := range [][3]uint8{{0, 2, 3}, {4, 6, 3}, {4, 2, 9}} {
+		if _, err := QuickLog(dir, moods[0], moods[1], moods[2]); err == nil {
+			t.Errorf("QuickLog(%v) succeeded", moods)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("QuickLog with invalid moods created %d files", len(entries))
+	}
+}
+
+// TestConcurrentUse is meant for go test -race: readers run alongside a writer on one shared Entry.
+func TestConcurrentUse(t *testing.T) {
+	p := &Entry{Path: entryPath(t.TempDir(), testDate), Body: []byte("start\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	const rounds = 200
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 0; i < rounds; i++ {
+			p.SetBody([]byte(fmt.Sprintf("round %d\n", i)))
+			p.SetHighMood(uint8(i%5 + 1))
+			p.SetLowMood(1)
+			p.SetAverageMood(3)
+			if err := p.Append([]byte("more words")); err != nil {
+				t.Error(err)
+			}
+			if i%10 == 0 {
+				if err := p.Save(); err != nil {
+					t.Error(err)
+				}
+				if _, err := p.Load(); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				p.WordCount()
+				for _, w := range p.Words() {
+					_ = string(w)
+				}
+				p.Rated()
+				p.MeetsGoal()
+				p.ChangedFields()
+				if _, err := p.Render(); err != nil {
+					t.Error(err)
+				}
+				if _, err := io.ReadAll(p.BodyReader()); err != nil {
+					t.Error(err)
+				}
+				if _, err := p.Title(); err != nil {
+					t.Error(err)
+				}
+				if _, err := p.Diff(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if p.HighMood != (rounds-1)%5+1 || !strings.HasPrefix(string(p.Body), fmt.Sprintf("round %d\n", rounds-1)) {
+		t.Errorf("after the writer finished, HighMood = %d, Body = %q", p.HighMood, p.Body)
+	}
+}
+
+func TestPromptFieldsFromTags(t *testing.T) {
+	fields := promptFieldsOf(reflect.TypeOf(struct {
+		Focus    uint8 `prompt:"Focus today?" scale:"1-10"`
+		Plain    uint8
+		LowMood  uint8 `prompt:"Low?"`
+		HighMood uint8 `prompt:"High?"`
+	}{}))
+	var texts []string
+	for _, f := range fields {
+		texts = append(texts, f.text)
+	}
+	if want := []string{"Focus today? (1-10) ", "High? (1-5) ", "Low? (1-5) "}; !reflect.DeepEqual(texts, want) {
+		t.Fatalf("prompts = %q, want %q", texts, want)
+	}
+	if fields[0].name != "Focus" || fields[0].index != 0 {
+		t.Errorf("Focus prompt is for field %s at %d", fields[0].name, fields[0].index)
+	}
+	if rating, ok := fields[0].validate("10"); !ok || rating != 10 {
+		t.Errorf("Focus validator rejected 10")
+	}
+	if _, ok := fields[0].validate("11"); ok {
+		t.Errorf("Focus validator accepted 11")
+	}
+	if fields[1].validate != nil {
+		t.Error("a prompt without a scale has its own validator, want the default 1-5")
+	}
+}
+
+func TestPromptFieldsBadTags(t *testing.T) {
+	for name, typ := range map[string]reflect.Type{
+		"not uint8": reflect.TypeOf(struct {
+			Notes string `prompt:"Notes?"`
+		}{}),
+		"bad scale": reflect.TypeOf(struct {
+			Focus uint8 `prompt:"Focus?" scale:"5-1"`
+		}{}),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("promptFieldsOf with a tag that is %s didn't panic", name)
+				}
+			}()
+			promptFieldsOf(typ)
+		}()
+	}
+}
+
+func TestPromptsFromEntryTags(t *testing.T) {
+	var out strings.Builder
+	if err := (&Entry{}).PromptForMetadata(strings.NewReader("4\n2\n3\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "High mood for the day? (1-5) Low mood for the day? (1-5) Average mood for the day? (1-5) "
+	if got := out.String(); got != want {
+		t.Errorf("prompts = %q, want %q", got, want)
+	}
+}
+
+func TestCombinedMoods(t *testing.T) {
+	for _, tt := range []struct {
+		name, input    string
+		high, low, avg uint8
+		retries        int
+	}{
+		{"valid", "4 2 3\n", 4, 2, 3, 0},
+		{"partly invalid", "4 9 3\n4 2\nfour two three\n5 1 2\n", 5, 1, 2, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Entry{CombinedMoods: true}
+			var out strings.Builder
+			if err := p.PromptForMetadata(strings.NewReader(tt.input), &out); err != nil {
+				t.Fatal(err)
+			}
+			if p.HighMood != tt.high || p.LowMood != tt.low || p.AverageMood != tt.avg {
+				t.Errorf("moods = %d/%d/%d, want %d/%d/%d", p.HighMood, p.LowMood, p.AverageMood, tt.high, tt.low, tt.avg)
+			}
+			prompt := "Moods for the day? (high low average, 1-5 each) "
+			if got := strings.Count(out.String(), prompt); got != tt.retries+1 {
+				t.Errorf("asked %d times, want %d: %q", got, tt.retries+1, out.String())
+			}
+			if got := strings.Count(out.String(), "Unrecognized input"); got != tt.retries {
+				t.Errorf("rejected %d lines, want %d", got, tt.retries)
+			}
+		})
+	}
+}
+
+func TestCombinedMoodsAsksOnlyUnset(t *testing.T) {
+	p := &Entry{CombinedMoods: true, HighMood: 4}
+	var out strings.Builder
+	if err := p.PromptForMetadata(strings.NewReader("2 3\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Moods for the day? (low average, 1-5 each) "; out.String() != want {
+		t.Errorf("prompt = %q, want %q", out.String(), want)
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want 4/2/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+}
+
+func TestScaleLabels(t *testing.T) {
+	p := &Entry{ScaleLabels: map[uint8]string{5: "great", 1: "terrible"}}
+	var energy uint8
+	p.AddPrompt("Energy?", func(v uint8) { energy = v })
+	var out strings.Builder
+	// The labels don't change what is accepted: 6 is still out of range.
+	if err := p.PromptForMetadata(strings.NewReader("6\n4\n2\n3\n5\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"High mood for the day? (1-5: 1=terrible, 5=great) ",
+		"Low mood for the day? (1-5: 1=terrible, 5=great) ",
+		"Average mood for the day? (1-5: 1=terrible, 5=great) ",
+		"Energy? (1=terrible, 5=great) ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("prompts %q don't include %q", out.String(), want)
+		}
+	}
+	if p.HighMood != 4 || p.LowMood != 2 || p.AverageMood != 3 || energy != 5 {
+		t.Errorf("answers = %d/%d/%d, energy %d, want 4/2/3, 5", p.HighMood, p.LowMood, p.AverageMood, energy)
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	contents := "---\nhighmood: 4\n---\n" + strings.Repeat("words ", 50)
+	path := writeEntry(t, t.TempDir(), testDate, contents)
+	for _, tt := range []struct {
+		max     int64
+		wantErr error
+	}{
+		{0, nil},
+		{int64(len(contents)), nil},
+		{int64(len(contents)) - 1, ErrBodyTooLarge},
+		{10, ErrBodyTooLarge},
+	} {
+		p := &Entry{Path: path, MaxBodyBytes: tt.max}
+		if _, err := p.Load(); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Load with MaxBodyBytes %d = %v, want %v", tt.max, err, tt.wantErr)
+		}
+	}
+
+	// A compressed file is limited by its decompressed size too.
+	zipped, err := gzipped([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(zipped)) >= int64(len(contents))-1 {
+		t.Fatalf("compressed test file is %d bytes, not smaller than %d", len(zipped), len(contents))
+	}
+	gz := path + ".gz"
+	if err := os.WriteFile(gz, zipped, 0666); err != nil {
+		t.Fatal(err)
+	}
+	p := &Entry{Path: gz, MaxBodyBytes: int64(len(contents)) - 1}
+	if _, err := p.Load(); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Load of a compressed file over MaxBodyBytes once decompressed = %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	p := &Entry{}
+	if err := p.SetMetadata(map[string]uint8{"HighMood": 5, "lowmood": 1, "AverageMood": 3}); err != nil {
+		t.Fatal(err)
+	}
+	if p.HighMood != 5 || p.LowMood != 1 || p.AverageMood != 3 {
+		t.Errorf("moods = %d/%d/%d, want 5/1/3", p.HighMood, p.LowMood, p.AverageMood)
+	}
+	if got := p.ChangedFields(); !reflect.DeepEqual(got, []string{"AverageMood", "HighMood", "LowMood"}) {
+		t.Errorf("ChangedFields = %v", got)
+	}
+}
+
+func TestSetMetadataInvalid(t *testing.T) {
+	p := &Entry{HighMood: 4}
+	err := p.SetMetadata(map[string]uint8{"HighMood": 2, "LowMood": 0, "AverageMood": 6, "Mood": 3})
+	if err == nil {
+		t.Fatal("SetMetadata with invalid values succeeded")
+	}
+	for _, want := range []string{"LowMood: 0", "AverageMood: 6", "Mood is not a rating field"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("SetMetadata error = %q, missing %q", err, want)
+		}
+	}
+	if p.HighMood != 4 || p.LowMood != 0 || p.AverageMood != 0 {
+		t.Errorf("moods after a rejected SetMetadata = %d/%d/%d, want 4/0/0", p.HighMood, p.LowMood, p.AverageMood)
+	}
+
+	// A MoodValidator decides what is accepted, as it does for prompts.
+	p = &Entry{MoodValidator: skippable}
+	if err := p.SetMetadata(map[string]uint8{"HighMood": 5}); err != nil || p.HighMood != 5 {
+		t.Errorf("SetMetadata with a MoodValidator = %v, HighMood %d", err, p.HighMood)
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	const fm = "---\nseconds: 0\nlowmood: 0\nhighmood: 4\naveragemood: 0\n---\n"
+	for _, tt := range []struct {
+		sep  Separator
+		body string
+		want string
+	}{
+		{KeepSeparator, "body\n", "body\n"},
+		{KeepSeparator, "\n\nbody\n", "\n\nbody\n"},
+		{NoBlankLine, "body\n", "body\n"},
+		{NoBlankLine, "\n\nbody\n", "body\n"},
+		{OneBlankLine, "body\n", "\nbody\n"},
+		{OneBlankLine, "\n\n\nbody\n", "\nbody\n"},
+	} {
+		p := &Entry{Path: entryPath(t.TempDir(), testDate), HighMood: 4, Body: []byte(tt.body), Separator: tt.sep}
+		got, err := p.Render()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != fm+tt.want {
+			t.Errorf("Render with Separator %d of %q = %q, want %q", tt.sep, tt.body, got, fm+tt.want)
+		}
+		// Loading and saving again keeps the same separator rather than adding to it.
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+		reloaded := &Entry{Path: p.Path, Separator: tt.sep}
+		if _, err := reloaded.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if err := reloaded.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if got := readString(t, p.Path); got != fm+tt.want {
+			t.Errorf("file after a second Save with Separator %d = %q, want %q", tt.sep, got, fm+tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package journalentry
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonEntry is the JSON form of an Entry written by ToJSON.
+type jsonEntry struct {
+	Path         string   `json:"path"`
+	Date         string   `json:"date,omitempty"`
+	Seconds      uint32   `json:"seconds"`
+	LowMood      uint8    `json:"lowMood"`
+	HighMood     uint8    `json:"highMood"`
+	AverageMood  uint8    `json:"averageMood"`
+	Energy       uint8    `json:"energy,omitempty"`
+	SleepQuality uint8    `json:"sleepQuality,omitempty"`
+	Pinned       bool     `json:"pinned,omitempty"`
+	Private      bool     `json:"private,omitempty"`
+	Attachments  []string `json:"attachments,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Headline     string   `json:"title,omitempty"`
+	WordGoal     int      `json:"wordGoal,omitempty"`
+	Body         string   `json:"body"`
+}
+
+// ToJSON returns p's frontmatter fields, body, and path as a JSON object, along with its date as "2006-01-02"
+// if p.Path has one. Unknown frontmatter keys and options are not included.
+func (p *Entry) ToJSON() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	j := jsonEntry{
+		Path:         p.Path,
+		Seconds:      p.Seconds,
+		LowMood:      p.LowMood,
+		HighMood:     p.HighMood,
+		AverageMood:  p.AverageMood,
+		Energy:       p.Energy,
+		SleepQuality: p.SleepQuality,
+		Pinned:       p.Pinned,
+		Private:      p.Private,
+		Attachments:  p.Attachments,
+		Tags:         p.Tags,
+		Headline:     p.Headline,
+		WordGoal:     p.WordGoal,
+		Body:         string(p.Body),
+	}
+	if date, err := p.Date(); err == nil {
+		j.Date = date.Format("2006-01-02")
+	}
+	return json.Marshal(j)
+}
+
+// FromJSON returns the Entry described by data, as written by ToJSON. The Entry's date comes from its path.
+// It is not loaded or saved, and an error is returned if a rating is out of the range 1-5.
+func FromJSON(data []byte) (*Entry, error) {
+	var j jsonEntry
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	p := &Entry{
+		Path:         j.Path,
+		Seconds:      j.Seconds,
+		LowMood:      j.LowMood,
+		HighMood:     j.HighMood,
+		AverageMood:  j.AverageMood,
+		Energy:       j.Energy,
+		SleepQuality: j.SleepQuality,
+		Pinned:       j.Pinned,
+		Private:      j.Private,
+		Attachments:  j.Attachments,
+		Tags:         j.Tags,
+		Headline:     j.Headline,
+		WordGoal:     j.WordGoal,
+		Body:         []byte(j.Body),
+	}
+	for _, r := range p.ratings() {
+		if r.value > 5 {
+			return nil, fmt.Errorf("%s %d is out of range 1-5", r.field, r.value)
+		}
+	}
+	if j.Date != "" {
+		if date, err := p.Date(); err != nil || date.Format("2006-01-02") != j.Date {
+			return nil, fmt.Errorf("date %s doesn't match path %s", j.Date, j.Path)
+		}
+	}
+	return p, nil
+}
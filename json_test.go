@@ -0,0 +1,51 @@
+package journalentry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	p := &Entry{
+		Path:        entryPath("journal", testDate),
+		Seconds:     300,
+		LowMood:     2,
+		HighMood:    5,
+		AverageMood: 3,
+		Pinned:      true,
+		Tags:        []string{"work", "travel"},
+		Headline:    "A long day",
+		Body:        []byte("Wrote \"quotes\" and <tags>.\n"),
+		ModTime:     time.Now(),
+	}
+	data, err := p.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"date":"2020-01-02"`) {
+		t.Errorf("ToJSON = %s, want the date", data)
+	}
+	got, err := FromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ModTime = time.Time{}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("FromJSON(ToJSON()) = %+v, want %+v", got, p)
+	}
+}
+
+func TestFromJSONInvalid(t *testing.T) {
+	path := `"path":"journal/2020-01-02-Journal-Entry-for-Jan-2.md"`
+	for name, data := range map[string]string{
+		"mood out of range":  `{` + path + `,"highMood":6}`,
+		"date doesn't match": `{` + path + `,"date":"2020-01-03"}`,
+		"not JSON":           `highmood: 4`,
+	} {
+		if p, err := FromJSON([]byte(data)); err == nil {
+			t.Errorf("FromJSON with %s = %+v, want an error", name, p)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package journalentry
+
+import (
+	"bytes"
+	"errors"
+	"unicode"
+)
+
+// minLanguageWords is the fewest words DetectLanguage will guess a language from.
+const minLanguageWords = 10
+
+// stopwords holds common short words of each language DetectLanguage recognizes, keyed by ISO 639-1 code.
+var stopwords = map[string][]string{
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "es", "mit", "ein", "eine", "auf", "zu", "war", "heute"},
+	"en": {"the", "and", "is", "was", "to", "of", "it", "that", "with", "for", "my", "today", "this", "but", "have"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "en", "un", "una", "por", "con", "hoy", "pero"},
+	"fr": {"le", "la", "les", "et", "est", "que", "de", "un", "une", "pour", "avec", "je", "pas", "mais", "aujourd'hui"},
+	"it": {"il", "la", "gli", "e", "che", "di", "un", "una", "per", "con", "non", "sono", "oggi", "ma", "ho"},
+	"nl": {"de", "het", "een", "en", "is", "van", "ik", "niet", "met", "voor", "op", "dat", "was", "maar", "vandaag"},
+	"pt": {"o", "os", "as", "e", "que", "de", "um", "uma", "para", "com", "não", "eu", "hoje", "mas", "foi"},
+}
+
+// DetectLanguage guesses the ISO 639-1 code of the language p.Body is written in by counting common words.
+// It returns an empty string if the body is too short to judge, and an error if no known language is recognized.
+func (p *Entry) DetectLanguage() (string, error) {
+	words := p.Words()
+	if len(words) < minLanguageWords {
+		return "", nil
+	}
+	counts := make(map[string]int)
+	for _, w := range words {
+		w = bytes.ToLower(bytes.TrimFunc(w, func(r rune) bool { return unicode.IsPunct(r) && r != '\'' }))
+		for lang, list := range stopwords {
+			for _, s := range list {
+				if string(w) == s {
+					counts[lang]++
+				}
+			}
+		}
+	}
+	best, bestCount := "", 0
+	for lang, n := range counts {
+		if n > bestCount || (n == bestCount && lang < best) {
+			best, bestCount = lang, n
+		}
+	}
+	if best == "" {
+		return "", errors.New("language not recognized")
+	}
+	return best, nil
+}
@@ -0,0 +1,28 @@
+package journalentry
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	for _, tt := range []struct{ body, want string }{
+		{"Today was a good day and the weather was nice, so I went for a walk with my dog. It was the best part of the week.", "en"},
+		{"Heute war ein guter Tag, und das Wetter war schön. Ich bin mit dem Hund spazieren gegangen, es war nicht kalt.", "de"},
+		{"Hoy fue un buen día y el tiempo era bueno, así que salí a caminar con el perro por la tarde con una amiga.", "es"},
+		{"Too short to tell.", ""},
+	} {
+		p := &Entry{Body: []byte(tt.body)}
+		got, err := p.DetectLanguage()
+		if err != nil || got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, %v, want %q", tt.body, got, err, tt.want)
+		}
+		if string(p.Body) != tt.body {
+			t.Errorf("DetectLanguage changed Body to %q", p.Body)
+		}
+	}
+}
+
+func TestDetectLanguageUnrecognized(t *testing.T) {
+	p := &Entry{Body: []byte("zxq vbn mlk jhg fds poi uyt rew qaz wsx edc")}
+	if got, err := p.DetectLanguage(); err == nil {
+		t.Errorf("DetectLanguage of gibberish = %q, want an error", got)
+	}
+}
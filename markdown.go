@@ -0,0 +1,160 @@
+package journalentry
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const (
+	headingRegex = `^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`
+	ruleRegex    = `^(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`
+	fenceRegex   = "^(```+|~~~+)"
+	// bulletRegex and numberRegex match list items, capturing the item's text.
+	bulletRegex = `^[-*+][ \t]+(.*)$`
+	numberRegex = `^\d{1,9}[.)][ \t]+(.*)$`
+	// inline regexes run on escaped text, so "&" in a link destination is already "&amp;".
+	linkRegex   = `\[([^\]]+)\]\(((?:https?://|mailto:)[^()\s]+|[^:()\s]+)\)`
+	strongRegex = `\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`
+	emRegex     = `\*(\S(?:.*?\S)?)\*|(^|\W)_(\S(?:.*?\S)?)_(\W|$)`
+)
+
+// RenderHTML returns the Markdown in body as HTML. It supports the parts of Markdown an Entry body is likely to use:
+// paragraphs, ATX headings ("## Heading"), horizontal rules, unordered ("-", "*", "+") and ordered ("1.") lists,
+// fenced code blocks, inline code, links, and strong and emphasized text. Raw HTML is escaped rather than passed through,
+// and links are kept only to http, https, mailto, and relative destinations. Block quotes, tables, images, setext headings,
+// indented code blocks, and reference links aren't supported and are rendered as plain text; nested list items are
+// rendered as items of the list they're in.
+func RenderHTML(body []byte) string {
+	r := &renderer{
+		heading: regexp.MustCompile(headingRegex),
+		rule:    regexp.MustCompile(ruleRegex),
+		fence:   regexp.MustCompile(fenceRegex),
+		bullet:  regexp.MustCompile(bulletRegex),
+		number:  regexp.MustCompile(numberRegex),
+		link:    regexp.MustCompile(linkRegex),
+		strong:  regexp.MustCompile(strongRegex),
+		em:      regexp.MustCompile(emRegex),
+	}
+	lines := strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimLeft(line, " ")
+		blank := r.blank
+		r.blank = trimmed == ""
+		switch {
+		case trimmed == "" && r.list != "":
+			// A blank line ends a list item, but the list goes on if another item follows.
+			r.flushLines()
+		case trimmed == "":
+			r.flush()
+		case r.fence.MatchString(trimmed):
+			r.flush()
+			fence := r.fence.FindString(trimmed)
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			r.code(code)
+		case r.heading.MatchString(trimmed):
+			r.flush()
+			m := r.heading.FindStringSubmatch(trimmed)
+			fmt.Fprintf(&r.b, "<h%d>%s</h%[1]d>\n", len(m[1]), r.inline(m[2]))
+		case r.rule.MatchString(trimmed):
+			r.flush()
+			r.b.WriteString("<hr>\n")
+		case r.bullet.MatchString(trimmed):
+			r.item("ul", r.bullet.FindStringSubmatch(trimmed)[1])
+		case r.number.MatchString(trimmed):
+			r.item("ol", r.number.FindStringSubmatch(trimmed)[1])
+		default:
+			// Other text continues the paragraph or list item, unless a blank line came first.
+			if blank && r.list != "" {
+				r.flush()
+			}
+			r.lines = append(r.lines, trimmed)
+		}
+	}
+	r.flush()
+	return r.b.String()
+}
+
+// renderer holds the state of RenderHTML: the lines of the paragraph or list item being read, and the list it is in, if any.
+type renderer struct {
+	heading, rule, fence, bullet, number, link, strong, em *regexp.Regexp
+
+	b     strings.Builder
+	list  string // "ul" or "ol" while in a list
+	lines []string
+	blank bool // whether the last line was blank
+}
+
+// item starts a new list item with text in a list of kind, ending the paragraph, item, or other list before it.
+func (r *renderer) item(kind, text string) {
+	if r.list != kind {
+		r.flush()
+		r.list = kind
+		fmt.Fprintf(&r.b, "<%s>\n", kind)
+	} else {
+		r.flushLines()
+	}
+	r.lines = append(r.lines, text)
+}
+
+// flush writes out the paragraph or list being read.
+func (r *renderer) flush() {
+	r.flushLines()
+	if r.list != "" {
+		fmt.Fprintf(&r.b, "</%s>\n", r.list)
+		r.list = ""
+	}
+}
+
+// flushLines writes out the lines read so far as a paragraph, or a list item if in a list.
+func (r *renderer) flushLines() {
+	if len(r.lines) == 0 {
+		return
+	}
+	tag := "p"
+	if r.list != "" {
+		tag = "li"
+	}
+	fmt.Fprintf(&r.b, "<%s>%s</%[1]s>\n", tag, r.inline(strings.Join(r.lines, "\n")))
+	r.lines = nil
+}
+
+// code writes lines as a code block.
+func (r *renderer) code(lines []string) {
+	text := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		text += "\n"
+	}
+	fmt.Fprintf(&r.b, "<pre><code>%s</code></pre>\n", html.EscapeString(text))
+}
+
+// inline escapes text and renders its code spans, links, and strong and emphasized text.
+func (r *renderer) inline(text string) string {
+	var b strings.Builder
+	// Splitting on backticks leaves code spans at the odd indexes, unless the last backtick is unmatched.
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		switch {
+		case i%2 == 1 && i < len(parts)-1:
+			fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(part))
+		case i%2 == 1:
+			b.WriteString("`" + r.span(part))
+		default:
+			b.WriteString(r.span(part))
+		}
+	}
+	return b.String()
+}
+
+// span escapes text, which has no code spans, and renders its links and strong and emphasized text.
+func (r *renderer) span(text string) string {
+	text = html.EscapeString(text)
+	text = r.link.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = r.strong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	return r.em.ReplaceAllString(text, "$2<em>$1$3</em>$4")
+}
@@ -0,0 +1,28 @@
+package journalentry
+
+import "testing"
+
+func TestRenderHTML(t *testing.T) {
+	for _, tt := range []struct {
+		name, markdown, want string
+	}{
+		{"paragraphs", "one\ntwo\n\nthree\n", "<p>one\ntwo</p>\n<p>three</p>\n"},
+		{"raw HTML", "a <b>bold</b> & \"quoted\" claim", "<p>a &lt;b&gt;bold&lt;/b&gt; &amp; &#34;quoted&#34; claim</p>\n"},
+		{"headings", "# Title\n## Morning ##\nprose\n####### seven", "<h1>Title</h1>\n<h2>Morning</h2>\n<p>prose\n####### seven</p>\n"},
+		{"hashtag", "#grateful for tea", "<p>#grateful for tea</p>\n"},
+		{"rules", "above\n\n* * *\n\n---\nbelow", "<p>above</p>\n<hr>\n<hr>\n<p>below</p>\n"},
+		{"unordered list", "Todo:\n- eggs\n* milk\n  and bread\n\nafter", "<p>Todo:</p>\n<ul>\n<li>eggs</li>\n<li>milk\nand bread</li>\n</ul>\n<p>after</p>\n"},
+		{"loose list", "1. first\n\n2. second\n", "<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n"},
+		{"list kinds", "- a\n1) b", "<ul>\n<li>a</li>\n</ul>\n<ol>\n<li>b</li>\n</ol>\n"},
+		{"emphasis", "**bold**, __also__, *it*, _too_, snake_case_name, 2 * 3 * 4", "<p><strong>bold</strong>, <strong>also</strong>, <em>it</em>, <em>too</em>, snake_case_name, 2 * 3 * 4</p>\n"},
+		{"inline code", "run `a <b> *c*` then `unmatched", "<p>run <code>a &lt;b&gt; *c*</code> then `unmatched</p>\n"},
+		{"fenced code", "```go\nx := <-ch\n\n*y*\n```\nafter", "<pre><code>x := &lt;-ch\n\n*y*\n</code></pre>\n<p>after</p>\n"},
+		{"links", "[site](https://example.com/?a=1&b=2) [notes](notes/jan.md) [bad](javascript:alert)", `<p><a href="https://example.com/?a=1&amp;b=2">site</a> <a href="notes/jan.md">notes</a> [bad](javascript:alert)</p>` + "\n"},
+		{"CRLF", "# Title\r\n\r\nbody\r\n", "<h1>Title</h1>\n<p>body</p>\n"},
+		{"empty", "\n\n", ""},
+	} {
+		if got := RenderHTML([]byte(tt.markdown)); got != tt.want {
+			t.Errorf("RenderHTML of %s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package journalentry
+
+import "regexp"
+
+const redacted = "[redacted]"
+
+// Redact returns a copy of p with every match of patterns in the body replaced by "[redacted]".
+// p is not modified. To hide the moods as well, zero them on the returned copy.
+func (p *Entry) Redact(patterns []*regexp.Regexp) *Entry {
+	p.mu.RLock()
+	c := p.clone()
+	p.mu.RUnlock()
+	for _, re := range patterns {
+		c.Body = re.ReplaceAllLiteral(c.Body, []byte(redacted))
+	}
+	return c
+}
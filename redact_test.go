@@ -0,0 +1,23 @@
+package journalentry
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	body := "Wrote to alice@example.com and bob@example.org today.\n"
+	p := &Entry{HighMood: 4, Body: []byte(body)}
+	email := regexp.MustCompile(`[\w.]+@[\w.]+\.\w+`)
+	c := p.Redact([]*regexp.Regexp{email})
+	if got, want := string(c.Body), "Wrote to [redacted] and [redacted] today.\n"; got != want {
+		t.Errorf("redacted Body = %q, want %q", got, want)
+	}
+	if c.HighMood != 4 {
+		t.Errorf("redacted HighMood = %d, want 4", c.HighMood)
+	}
+	c.HighMood = 0
+	if string(p.Body) != body || p.HighMood != 4 {
+		t.Errorf("original changed to %q, HighMood %d", p.Body, p.HighMood)
+	}
+}
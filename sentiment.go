@@ -0,0 +1,49 @@
+package journalentry
+
+import (
+	"bytes"
+	"unicode"
+)
+
+// moodWeight is the share of Sentiment taken from AverageMood when the entry is rated; the body supplies the rest.
+const moodWeight = 0.5
+
+var (
+	positiveWords = wordSet("good", "great", "happy", "love", "loved", "glad", "fun", "calm", "grateful", "excited", "wonderful", "relaxed", "proud", "enjoyed", "nice", "better", "best", "amazing", "peaceful", "joy")
+	negativeWords = wordSet("bad", "sad", "angry", "tired", "awful", "hate", "hated", "anxious", "stressed", "worried", "terrible", "lonely", "upset", "worse", "worst", "sick", "frustrated", "afraid", "depressed", "miserable")
+)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Sentiment returns a score from -1 (negative) to 1 (positive) for p.
+// The body's score is the balance of positive and negative words from a small lexicon, (pos-neg)/(pos+neg),
+// or 0 if it has none. For a rated entry this is blended equally with AverageMood mapped from 1-5 onto -1 to 1;
+// an unrated entry scores on its body alone.
+func (p *Entry) Sentiment() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	pos, neg := 0, 0
+	for _, w := range p.words() {
+		w = bytes.ToLower(bytes.TrimFunc(w, unicode.IsPunct))
+		if positiveWords[string(w)] {
+			pos++
+		} else if negativeWords[string(w)] {
+			neg++
+		}
+	}
+	body := 0.0
+	if pos+neg > 0 {
+		body = float64(pos-neg) / float64(pos+neg)
+	}
+	if p.AverageMood == 0 {
+		return body
+	}
+	mood := (float64(p.AverageMood) - 3) / 2
+	return moodWeight*mood + (1-moodWeight)*body
+}
@@ -0,0 +1,23 @@
+package journalentry
+
+import "testing"
+
+func TestSentiment(t *testing.T) {
+	for _, tt := range []struct {
+		body string
+		avg  uint8
+		want float64
+	}{
+		{"A great day. I loved the walk and felt calm and happy!", 0, 1},
+		{"Awful. Tired, anxious and sad all day.", 0, -1},
+		{"Good food, bad weather.", 0, 0},
+		{"Nothing much happened.", 0, 0},
+		{"A great day.", 1, 0},
+		{"Nothing much happened.", 5, 0.5},
+	} {
+		p := &Entry{Body: []byte(tt.body), AverageMood: tt.avg}
+		if got := p.Sentiment(); got != tt.want {
+			t.Errorf("Sentiment of %q with average %d = %v, want %v", tt.body, tt.avg, got, tt.want)
+		}
+	}
+}
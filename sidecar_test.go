@@ -0,0 +1,248 @@
+package journalentry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSidecarEntry writes an Entry for date in dir whose frontmatter meta is in a sidecar file beside body.
+func writeSidecarEntry(t *testing.T, dir string, date time.Time, meta, body string) string {
+	t.Helper()
+	path := entryPath(dir, date)
+	if err := os.WriteFile(path, []byte(body), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sidecarPath(path), []byte(meta), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// assertMissing fails t if any of paths exists.
+func assertMissing(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s exists: %v", path, err)
+		}
+	}
+}
+
+func TestSidecarRoundTrip(t *testing.T) {
+	path := entryPath(t.TempDir(), testDate)
+	p := &Entry{Path: path, Sidecar: true, HighMood: 4, Tags: []string{"work"}, Body: []byte("just prose\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, path); got != "just prose\n" {
+		t.Errorf("body file = %q, want only the body", got)
+	}
+	if got := readString(t, p.SidecarPath()); !strings.Contains(got, "highmood: 4\n") || strings.Contains(got, "---") {
+		t.Errorf("sidecar file = %q, want plain YAML", got)
+	}
+	if p.SidecarPath() != strings.TrimSuffix(path, ".md")+".yml" {
+		t.Errorf("SidecarPath = %s", p.SidecarPath())
+	}
+	if !IsEntry(path) || IsEntry(p.SidecarPath()) {
+		t.Errorf("IsEntry(body) = %t, IsEntry(sidecar) = %t, want true, false", IsEntry(path), IsEntry(p.SidecarPath()))
+	}
+
+	loaded := &Entry{Path: path, Sidecar: true}
+	if _, err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HighMood != 4 || !reflect.DeepEqual(loaded.Tags, []string{"work"}) || string(loaded.Body) != "just prose\n" {
+		t.Errorf("Load = HighMood %d, Tags %v, Body %q", loaded.HighMood, loaded.Tags, loaded.Body)
+	}
+	if entries, err := Entries(filepath.Dir(path)); err != nil || len(entries) != 1 {
+		t.Errorf("Entries = %v, %v, want only the body file", paths(entries), err)
+	}
+}
+
+func TestSingleFileByDefault(t *testing.T) {
+	path := entryPath(t.TempDir(), testDate)
+	p := &Entry{Path: path, HighMood: 4, Body: []byte("prose\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, path); !strings.HasPrefix(got, "---\n") || !strings.Contains(got, "highmood: 4\n") {
+		t.Errorf("file = %q, want frontmatter and body together", got)
+	}
+	assertMissing(t, p.SidecarPath())
+}
+
+func TestLoadDetectsSidecar(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSidecarEntry(t, dir, testDate, "highmood: 4\nlowmood: 2\n", "prose\n")
+	p := &Entry{Path: path}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Sidecar || p.HighMood != 4 || string(p.Body) != "prose\n" {
+		t.Errorf("Load = Sidecar %t, HighMood %d, Body %q", p.Sidecar, p.HighMood, p.Body)
+	}
+	p.SetLowMood(1)
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, path); got != "prose\n" {
+		t.Errorf("body file after Save = %q, want it still without frontmatter", got)
+	}
+	if got := readString(t, sidecarPath(path)); !strings.Contains(got, "lowmood: 1\n") {
+		t.Errorf("sidecar after Save = %q", got)
+	}
+
+	meta := &Entry{Path: path}
+	if err := meta.LoadMetadata(); err != nil || !meta.Sidecar || meta.LowMood != 1 {
+		t.Errorf("LoadMetadata = %v, Sidecar %t, LowMood %d", err, meta.Sidecar, meta.LowMood)
+	}
+}
+
+func TestSidecarDiffAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	p := &Entry{Path: entryPath(dir, testDate), Sidecar: true, HighMood: 4, Body: []byte("prose\n")}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if diff, err := p.Diff(); err != nil || diff != "" {
+		t.Errorf("Diff of a saved sidecar Entry = %q, %v", diff, err)
+	}
+	if ok, err := (&Entry{Path: p.Path}).VerifyRoundTrip(); !ok || err != nil {
+		t.Errorf("VerifyRoundTrip of a saved sidecar Entry = %t, %v", ok, err)
+	}
+
+	p.SetHighMood(2)
+	p.SetBody([]byte("changed\n"))
+	diff, err := p.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"-highmood: 4\n", "+highmood: 2\n", "-prose\n", "+changed\n", p.SidecarPath()} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Diff = %q, missing %q", diff, want)
+		}
+	}
+
+	if err := os.WriteFile(p.SidecarPath(), []byte("lowmood: 0\nhighmood: 4\nseconds: 0\naveragemood: 0\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := (&Entry{Path: p.Path}).VerifyRoundTrip(); ok || err == nil || !strings.Contains(err.Error(), p.SidecarPath()) {
+		t.Errorf("VerifyRoundTrip of a reordered sidecar = %t, %v", ok, err)
+	}
+}
+
+func TestSidecarRenameAndTrash(t *testing.T) {
+	dir, trashDir := t.TempDir(), t.TempDir()
+	path := writeSidecarEntry(t, dir, testDate, "highmood: 4\n", "prose\n")
+	p := &Entry{Path: path}
+	if err := p.Rename(testDate.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	assertMissing(t, path, sidecarPath(path))
+	if got := readString(t, sidecarPath(p.Path)); got != "highmood: 4\n" {
+		t.Errorf("sidecar after Rename = %q", got)
+	}
+
+	renamed := p.Path
+	if err := p.Trash(trashDir); err != nil {
+		t.Fatal(err)
+	}
+	assertMissing(t, renamed, sidecarPath(renamed))
+	if got := readString(t, sidecarPath(p.Path)); got != "highmood: 4\n" {
+		t.Errorf("sidecar after Trash = %q", got)
+	}
+	if entries, _ := os.ReadDir(trashDir); len(entries) != 2 {
+		t.Errorf("trash has %d files, want the body and sidecar", len(entries))
+	}
+
+	restored, err := Restore(p.Path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Entry{Path: restored}
+	if _, err := loaded.Load(); err != nil || !loaded.Sidecar || loaded.HighMood != 4 || string(loaded.Body) != "prose\n" {
+		t.Errorf("restored Entry = %v, Sidecar %t, HighMood %d, Body %q", err, loaded.Sidecar, loaded.HighMood, loaded.Body)
+	}
+	if entries, _ := os.ReadDir(trashDir); len(entries) != 0 {
+		t.Errorf("trash has %d files after Restore, want none", len(entries))
+	}
+}
+
+func TestSidecarFlattenAndNormalize(t *testing.T) {
+	root := t.TempDir()
+	src, dest := filepath.Join(root, "2020", "01"), filepath.Join(root, "all")
+	if err := os.MkdirAll(src, 0777); err != nil {
+		t.Fatal(err)
+	}
+	short := filepath.Join(src, testDate.Format(shortEntryFormat))
+	if err := os.WriteFile(short, []byte("prose\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sidecarPath(short), []byte("highmood: 4\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if moved, err := Flatten(root, dest); err != nil || moved != 1 {
+		t.Fatalf("Flatten = %d, %v, want 1", moved, err)
+	}
+	flat := filepath.Join(dest, filepath.Base(short))
+	assertMissing(t, short, sidecarPath(short))
+	if got := readString(t, sidecarPath(flat)); got != "highmood: 4\n" {
+		t.Errorf("sidecar after Flatten = %q", got)
+	}
+
+	if n, err := NormalizeFilenames(dest); err != nil || n != 1 {
+		t.Fatalf("NormalizeFilenames = %d, %v, want 1", n, err)
+	}
+	canonical := entryPath(dest, testDate)
+	assertMissing(t, flat, sidecarPath(flat))
+	if got := readString(t, sidecarPath(canonical)); got != "highmood: 4\n" {
+		t.Errorf("sidecar after NormalizeFilenames = %q", got)
+	}
+}
+
+func TestSidecarDedupe(t *testing.T) {
+	dir := t.TempDir()
+	first := writeSidecarEntry(t, dir, testDate, "highmood: 4\n", "morning\n")
+	second := withSuffix(first, 2)
+	if err := os.WriteFile(second, []byte("evening\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sidecarPath(second), []byte("lowmood: 2\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := DedupeSameDay(dir, KeepFirst); err != nil || n != 1 {
+		t.Fatalf("DedupeSameDay = %d, %v, want 1", n, err)
+	}
+	assertMissing(t, second, sidecarPath(second))
+	p := &Entry{Path: first}
+	if _, err := p.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Sidecar || p.HighMood != 4 || p.LowMood != 2 || string(p.Body) != "morning\n\nevening\n" {
+		t.Errorf("merged Entry = Sidecar %t, moods %d/%d, Body %q", p.Sidecar, p.HighMood, p.LowMood, p.Body)
+	}
+	if got := readString(t, first); got != "morning\n\nevening\n" {
+		t.Errorf("merged body file = %q, want no frontmatter", got)
+	}
+}
+
+func TestSidecarMigrateAll(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSidecarEntry(t, dir, testDate, "highmood: 4\n", "prose\n---\nnot frontmatter\n---\n")
+	if err := MigrateAll(dir, func(p *Entry) error {
+		p.Tags = []string{"migrated"}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, path); got != "prose\n---\nnot frontmatter\n---\n" {
+		t.Errorf("body file after MigrateAll = %q, want it unchanged", got)
+	}
+	if got := readString(t, sidecarPath(path)); !strings.Contains(got, "- migrated\n") || !strings.Contains(got, "highmood: 4\n") {
+		t.Errorf("sidecar after MigrateAll = %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+package journalentry
+
+import "sort"
+
+// ByDate reports whether a is dated before b, for use with SortEntries.
+// Entries whose dates can't be parsed sort after the rest, by path.
+func ByDate(a, b *Entry) bool {
+	da, errA := a.Date()
+	db, errB := b.Date()
+	switch {
+	case errA != nil && errB != nil:
+		return a.Path < b.Path
+	case errA != nil || errB != nil:
+		return errB != nil
+	}
+	return da.Before(db)
+}
+
+// ByWordCount reports whether a has fewer words than b, for use with SortEntries.
+func ByWordCount(a, b *Entry) bool {
+	return a.WordCount() < b.WordCount()
+}
+
+// SortEntries sorts entries in place by less. The sort is stable, so entries that are equal under less keep their order,
+// and sorting by one helper and then another orders by the second with ties broken by the first.
+func SortEntries(entries []*Entry, less func(a, b *Entry) bool) {
+	sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+}
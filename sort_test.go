@@ -0,0 +1,22 @@
+package journalentry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortEntries(t *testing.T) {
+	a := withBody(testDate, "one two three")
+	b := withBody(testDate.AddDate(0, 0, 1), "one")
+	c := withBody(testDate.AddDate(0, 0, 2), "one two three")
+	bad := &Entry{Path: "journal/notes.md", Body: []byte("one two")}
+	entries := []*Entry{c, bad, a, b}
+	SortEntries(entries, ByDate)
+	if want := []*Entry{a, b, c, bad}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("by date = %v, want %v", paths(entries), paths(want))
+	}
+	SortEntries(entries, ByWordCount)
+	if want := []*Entry{b, bad, a, c}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("by word count, ties by date = %v, want %v", paths(entries), paths(want))
+	}
+}
@@ -0,0 +1,38 @@
+package journalentry
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Split divides p.Body at each line beginning with heading, such as "## ", and returns one Entry per part.
+// Any text before the first heading becomes its own part.
+// The parts share p's date and metadata, with paths distinguished by the suffixes 1, 2, and so on.
+// p itself is not modified, and the parts are not saved.
+func (p *Entry) Split(heading string) ([]*Entry, error) {
+	if heading == "" {
+		return nil, errors.New("heading must not be empty")
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var parts [][]byte
+	var part []byte
+	for _, line := range bytes.SplitAfter(p.Body, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte(heading)) && len(bytes.TrimSpace(part)) > 0 {
+			parts = append(parts, part)
+			part = nil
+		}
+		part = append(part, line...)
+	}
+	if len(bytes.TrimSpace(part)) > 0 {
+		parts = append(parts, part)
+	}
+	entries := make([]*Entry, len(parts))
+	for i, body := range parts {
+		e := p.clone()
+		e.Body = body
+		e.Path = withSuffix(p.Path, i+1)
+		entries[i] = e
+	}
+	return entries, nil
+}
@@ -0,0 +1,46 @@
+package journalentry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	body := "## Morning\nCoffee.\n## Afternoon\nWork.\n### Detail\nMore work.\n## Evening\nSleep.\n"
+	p := &Entry{Path: entryPath("journal", testDate), HighMood: 4, Body: []byte(body)}
+	parts, err := p.Split("## ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"## Morning\nCoffee.\n", "## Afternoon\nWork.\n### Detail\nMore work.\n", "## Evening\nSleep.\n"}
+	var got []string
+	for i, part := range parts {
+		got = append(got, string(part.Body))
+		if wantPath := withSuffix(p.Path, i+1); part.Path != wantPath {
+			t.Errorf("parts[%d].Path = %s, want %s", i, part.Path, wantPath)
+		}
+		if part.HighMood != 4 {
+			t.Errorf("parts[%d].HighMood = %d, want 4", i, part.HighMood)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split bodies = %q, want %q", got, want)
+	}
+	if string(p.Body) != body || p.Path != entryPath("journal", testDate) {
+		t.Errorf("Split modified the original: %s %q", p.Path, p.Body)
+	}
+}
+
+func TestSplitKeepsPreamble(t *testing.T) {
+	p := &Entry{Path: entryPath("journal", testDate), Body: []byte("Intro.\n## One\nText.\n")}
+	parts, err := p.Split("## ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 || string(parts[0].Body) != "Intro.\n" {
+		t.Errorf("Split = %d parts, first %q", len(parts), parts[0].Body)
+	}
+	if _, err := p.Split(""); err == nil {
+		t.Error("Split with an empty heading succeeded")
+	}
+}
@@ -0,0 +1,77 @@
+package journalentry
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" driver
+)
+
+// sqliteDriver is the database/sql driver name ExportSQLite opens the database with, as registered by modernc.org/sqlite.
+const sqliteDriver = "sqlite"
+
+// ExportSQLite writes a row of metadata for each Entry in dir to an "entries" table in the SQLite database at dbPath,
+// with the columns date, high, low, average, seconds, words, and path. The table is dropped and recreated on each run.
+// The database is written with the pure-Go modernc.org/sqlite driver, so no C toolchain is needed.
+// As with Entries, files that fail to load are left out and their errors returned.
+func ExportSQLite(dir, dbPath string) error {
+	entries, loadErr := Entries(dir)
+	db, err := sql.Open(sqliteDriver, dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := exportSQL(db, entries); err != nil {
+		return err
+	}
+	return loadErr
+}
+
+// exportSQL replaces the entries table in db with a row for each of entries.
+func exportSQL(db *sql.DB, entries []*Entry) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+	if _, err := tx.Exec(`DROP TABLE IF EXISTS entries`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`CREATE TABLE entries (
+		date TEXT NOT NULL,
+		high INTEGER,
+		low INTEGER,
+		average INTEGER,
+		seconds INTEGER,
+		words INTEGER,
+		path TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(`INSERT INTO entries (date, high, low, average, seconds, words, path) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, p := range entries {
+		date, err := p.Date()
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Path, err)
+		}
+		if _, err := insert.Exec(date.Format("2006-01-02"), nullRating(p.HighMood), nullRating(p.LowMood), nullRating(p.AverageMood),
+			p.Seconds, p.WordCount(), p.Path); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// nullRating returns rating for a database column, with 0 for unrated as NULL.
+func nullRating(rating uint8) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(rating), Valid: rating != 0}
+}
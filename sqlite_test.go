@@ -0,0 +1,64 @@
+package journalentry
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExportSQLite(t *testing.T) {
+	dir := t.TempDir()
+	writeEntry(t, dir, testDate, "---\nseconds: 120\nhighmood: 4\nlowmood: 2\naveragemood: 3\n---\none two three\n")
+	writeEntry(t, dir, testDate.AddDate(0, 0, 1), "---\n---\nunrated\n")
+	dbPath := filepath.Join(t.TempDir(), "journal.db")
+	// Running twice recreates the table rather than adding to it.
+	for i := 0; i < 2; i++ {
+		if err := ExportSQLite(dir, dbPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := sql.Open(sqliteDriver, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query(`SELECT * FROM entries ORDER BY date`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"date", "high", "low", "average", "seconds", "words", "path"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	type row struct {
+		date               string
+		high, low, average sql.NullInt64
+		seconds, words     int
+		path               string
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.date, &r.high, &r.low, &r.average, &r.seconds, &r.words, &r.path); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rated := func(n int64) sql.NullInt64 { return sql.NullInt64{Int64: n, Valid: true} }
+	want := []row{
+		{"2020-01-02", rated(4), rated(2), rated(3), 120, 3, entryPath(dir, testDate)},
+		{"2020-01-03", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}, 0, 1, entryPath(dir, testDate.AddDate(0, 0, 1))},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %+v, want %+v", got, want)
+	}
+}
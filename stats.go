@@ -0,0 +1,326 @@
+package journalentry
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// ImprovingMoodRun returns how many times in a row AverageMood has risen on consecutive days, ending at the last rated entry in entries.
+// Entries must be sorted by date. A day without an entry ends the run; unrated entries are ignored rather than breaking it.
+func ImprovingMoodRun(entries []*Entry) int {
+	run := 0
+	var prev uint8
+	var last time.Time
+	for _, p := range entries {
+		if date, err := p.Date(); err == nil {
+			if !last.IsZero() && daysBetween(last, date) > 1 {
+				run, prev = 0, 0
+			}
+			last = date
+		}
+		if p.AverageMood == 0 {
+			continue
+		}
+		if prev != 0 && p.AverageMood > prev {
+			run++
+		} else {
+			run = 0
+		}
+		prev = p.AverageMood
+	}
+	return run
+}
+
+// MoodPoint is the average mood over a window ending on Date.
+type MoodPoint struct {
+	Date time.Time
+	Avg  float64
+}
+
+// RollingAverageMood returns, for each date in entries, the mean AverageMood of the rated entries in the window days ending on that date.
+// Entries must be sorted by date. Windows at the start of the series are partial, averaging whatever
+// rated entries they contain; dates whose window has no rated entries are left out.
+func RollingAverageMood(entries []*Entry, window int) []MoodPoint {
+	var points []MoodPoint
+	for i, p := range entries {
+		date, err := p.Date()
+		if err != nil {
+			continue
+		}
+		start := date.AddDate(0, 0, 1-window)
+		sum, n := 0, 0
+		for j := i; j >= 0; j-- {
+			d, err := entries[j].Date()
+			if err != nil {
+				continue
+			}
+			if d.Before(start) {
+				break
+			}
+			if entries[j].AverageMood != 0 {
+				sum += int(entries[j].AverageMood)
+				n++
+			}
+		}
+		if n > 0 {
+			points = append(points, MoodPoint{Date: date, Avg: float64(sum) / float64(n)})
+		}
+	}
+	return points
+}
+
+// GoalCompletionRate returns the fraction of the entries with a WordGoal that meet it, or 0 if none have one.
+func GoalCompletionRate(entries []*Entry) float64 {
+	withGoal, met := 0, 0
+	for _, p := range entries {
+		if p.WordGoal <= 0 {
+			continue
+		}
+		withGoal++
+		if p.MeetsGoal() {
+			met++
+		}
+	}
+	if withGoal == 0 {
+		return 0
+	}
+	return float64(met) / float64(withGoal)
+}
+
+// AverageWordCount returns the mean WordCount of entries, or 0 if there are none. Empty bodies count as 0 words.
+func AverageWordCount(entries []*Entry) float64 {
+	if len(entries) == 0 {
+		return 0
+	}
+	total := 0
+	for _, p := range entries {
+		total += p.WordCount()
+	}
+	return float64(total) / float64(len(entries))
+}
+
+// AverageWordCountByMonth returns the mean WordCount of entries for each month, keyed by the first day of the month.
+// Entries whose dates can't be parsed are left out.
+func AverageWordCountByMonth(entries []*Entry) map[time.Time]float64 {
+	byMonth := make(map[time.Time][]*Entry)
+	for _, p := range entries {
+		date, err := p.Date()
+		if err != nil {
+			continue
+		}
+		month := date.AddDate(0, 0, 1-date.Day())
+		byMonth[month] = append(byMonth[month], p)
+	}
+	averages := make(map[time.Time]float64, len(byMonth))
+	for month, es := range byMonth {
+		averages[month] = AverageWordCount(es)
+	}
+	return averages
+}
+
+// RatingCoverage returns the fraction of entries with all three moods set, or 0 if there are none.
+func RatingCoverage(entries []*Entry) float64 {
+	if len(entries) == 0 {
+		return 0
+	}
+	rated := 0
+	for _, p := range entries {
+		if p.Rated() {
+			rated++
+		}
+	}
+	return float64(rated) / float64(len(entries))
+}
+
+// sparks are the characters Sparkline draws for average moods 1 to 5.
+var sparks = []rune("▁▂▄▆█")
+
+// Sparkline returns one character per entry showing its AverageMood, from ▁ for 1 to █ for 5.
+// Unrated entries, and any with a rating above 5, are shown as a space.
+func Sparkline(entries []*Entry) string {
+	line := make([]rune, len(entries))
+	for i, p := range entries {
+		if p.AverageMood >= 1 && int(p.AverageMood) <= len(sparks) {
+			line[i] = sparks[p.AverageMood-1]
+		} else {
+			line[i] = ' '
+		}
+	}
+	return string(line)
+}
+
+// MoodDelta returns how much AverageMood rose from prev to curr, negative for a drop,
+// and whether both are rated so that the difference is meaningful.
+func MoodDelta(prev, curr *Entry) (int, bool) {
+	if prev == nil || curr == nil || prev.AverageMood == 0 || curr.AverageMood == 0 {
+		return 0, false
+	}
+	return int(curr.AverageMood) - int(prev.AverageMood), true
+}
+
+// Happiest returns up to n rated entries with the highest AverageMood, earlier dates first among equals.
+func Happiest(entries []*Entry, n int) []*Entry {
+	return rankByMood(entries, n, func(a, b uint8) bool { return a > b })
+}
+
+// Lowest returns up to n rated entries with the lowest AverageMood, earlier dates first among equals.
+func Lowest(entries []*Entry, n int) []*Entry {
+	return rankByMood(entries, n, func(a, b uint8) bool { return a < b })
+}
+
+func rankByMood(entries []*Entry, n int, better func(a, b uint8) bool) []*Entry {
+	var rated []*Entry
+	for _, p := range entries {
+		if p.AverageMood != 0 {
+			rated = append(rated, p)
+		}
+	}
+	SortEntries(rated, ByDate)
+	SortEntries(rated, func(a, b *Entry) bool { return better(a.AverageMood, b.AverageMood) })
+	if n < 0 {
+		n = 0
+	}
+	if len(rated) > n {
+		rated = rated[:n]
+	}
+	return rated
+}
+
+// MoodStats summarizes the AverageMood of a group of entries.
+type MoodStats struct {
+	Count int
+	Mean  float64
+}
+
+// TagMoodStats returns the AverageMood statistics of the rated entries in entries, grouped by tag.
+// An entry with several tags counts toward each; unrated entries are left out.
+func TagMoodStats(entries []*Entry) map[string]MoodStats {
+	sums := make(map[string]int)
+	stats := make(map[string]MoodStats)
+	for _, p := range entries {
+		if p.AverageMood == 0 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, tag := range p.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			sums[tag] += int(p.AverageMood)
+			s := stats[tag]
+			s.Count++
+			stats[tag] = s
+		}
+	}
+	for tag, s := range stats {
+		s.Mean = float64(sums[tag]) / float64(s.Count)
+		stats[tag] = s
+	}
+	return stats
+}
+
+// WritingHourHistogram returns how many of entries were last modified in each hour of the day in loc,
+// or in the local time zone if loc is nil. Entries without a ModTime, such as those never loaded or saved, are left out.
+func WritingHourHistogram(entries []*Entry, loc *time.Location) [24]int {
+	if loc == nil {
+		loc = time.Local
+	}
+	var hours [24]int
+	for _, p := range entries {
+		if !p.ModTime.IsZero() {
+			hours[p.ModTime.In(loc).Hour()]++
+		}
+	}
+	return hours
+}
+
+// WordCountPercentiles returns the word count at each percentile in ps, from 0 to 100, of entries.
+// Percentiles fall between the sorted counts by linear interpolation between the closest ranks
+// (the method spreadsheets call PERCENTILE.INC), and are rounded to the nearest word.
+// Percentiles outside 0-100 are clamped to it. The map is empty if entries is.
+func WordCountPercentiles(entries []*Entry, ps ...float64) map[float64]int {
+	result := make(map[float64]int, len(ps))
+	if len(entries) == 0 {
+		return result
+	}
+	counts := make([]int, len(entries))
+	for i, p := range entries {
+		counts[i] = p.WordCount()
+	}
+	sort.Ints(counts)
+	for _, pct := range ps {
+		rank := math.Max(0, math.Min(100, pct)) / 100 * float64(len(counts)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		frac := rank - float64(lower)
+		result[pct] = int(math.Round(float64(counts[lower]) + frac*float64(counts[upper]-counts[lower])))
+	}
+	return result
+}
+
+// ErrTooFewPoints is returned when there isn't enough data to compute a statistic.
+var ErrTooFewPoints = errors.New("too few data points")
+
+// WordMoodCorrelation returns the Pearson correlation between the word count and AverageMood of the rated entries in entries,
+// from -1 (more words on worse days) to 1 (more words on better days). It returns ErrTooFewPoints if fewer than two entries
+// are rated, or if the word counts or moods are all the same, so that the correlation is undefined.
+func WordMoodCorrelation(entries []*Entry) (float64, error) {
+	var words, moods []float64
+	for _, p := range entries {
+		if p.AverageMood != 0 {
+			words = append(words, float64(p.WordCount()))
+			moods = append(moods, float64(p.AverageMood))
+		}
+	}
+	if len(words) < 2 {
+		return 0, ErrTooFewPoints
+	}
+	meanWords, meanMoods := mean(words), mean(moods)
+	var cov, varWords, varMoods float64
+	for i := range words {
+		dw, dm := words[i]-meanWords, moods[i]-meanMoods
+		cov += dw * dm
+		varWords += dw * dw
+		varMoods += dm * dm
+	}
+	if varWords == 0 || varMoods == 0 {
+		return 0, ErrTooFewPoints
+	}
+	return cov / math.Sqrt(varWords*varMoods), nil
+}
+
+func mean(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// WordsPerActiveDay returns the words written in entries per day with writing: the total word count
+// over the number of distinct dates whose entries contain any words. Calendar days without entries, and days
+// whose entries are empty, don't count. It returns 0 if no entry has words.
+func WordsPerActiveDay(entries []*Entry) float64 {
+	days := make(map[time.Time]int)
+	for _, p := range entries {
+		date, err := p.Date()
+		if err != nil {
+			continue
+		}
+		days[date] += p.WordCount()
+	}
+	total, active := 0, 0
+	for _, words := range days {
+		if words > 0 {
+			total += words
+			active++
+		}
+	}
+	if active == 0 {
+		return 0
+	}
+	return float64(total) / float64(active)
+}
@@ -0,0 +1,317 @@
+package journalentry
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withAverages returns in-memory entries on consecutive days from testDate with the given average moods.
+func withAverages(moods ...uint8) []*Entry {
+	entries := make([]*Entry, len(moods))
+	for i, mood := range moods {
+		entries[i] = &Entry{Path: entryPath("journal", testDate.AddDate(0, 0, i)), AverageMood: mood}
+	}
+	return entries
+}
+
+func TestImprovingMoodRun(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		moods []uint8
+		want  int
+	}{
+		{"improving", []uint8{1, 2, 3, 5}, 3},
+		{"broken by a dip", []uint8{1, 2, 4, 3, 4, 5}, 2},
+		{"ending in a dip", []uint8{1, 2, 3, 2}, 0},
+		{"unrated days ignored", []uint8{2, 0, 3, 0, 4}, 2},
+		{"flat", []uint8{3, 3}, 0},
+		{"none", nil, 0},
+	} {
+		if got := ImprovingMoodRun(withAverages(tt.moods...)); got != tt.want {
+			t.Errorf("%s: ImprovingMoodRun(%v) = %d, want %d", tt.name, tt.moods, got, tt.want)
+		}
+	}
+}
+
+func TestImprovingMoodRunCalendarGap(t *testing.T) {
+	entries := withAverages(1, 2, 3, 4)
+	// A week without entries between the second and third rises ends the run.
+	for i, p := range entries[2:] {
+		p.Path = entryPath("journal", testDate.AddDate(0, 0, 9+i))
+	}
+	if got := ImprovingMoodRun(entries); got != 1 {
+		t.Errorf("ImprovingMoodRun across a week without entries = %d, want 1", got)
+	}
+	// An unrated entry fills its day, so it doesn't.
+	entries = withAverages(1, 2, 0, 3)
+	if got := ImprovingMoodRun(entries); got != 2 {
+		t.Errorf("ImprovingMoodRun past an unrated day = %d, want 2", got)
+	}
+}
+
+func TestRollingAverageMood(t *testing.T) {
+	points := RollingAverageMood(withAverages(0, 2, 4, 0, 3, 5), 3)
+	want := []float64{2, 3, 3, 3.5, 4}
+	if len(points) != len(want) {
+		t.Fatalf("RollingAverageMood = %v, want %d points", points, len(want))
+	}
+	for i, pt := range points {
+		if wantDate := testDate.AddDate(0, 0, i+1); !pt.Date.Equal(wantDate) || pt.Avg != want[i] {
+			t.Errorf("points[%d] = %v %v, want %v %v", i, pt.Date, pt.Avg, wantDate, want[i])
+		}
+	}
+}
+
+func TestGoalCompletionRate(t *testing.T) {
+	entries := []*Entry{
+		{Body: []byte("one two"), WordGoal: 2},
+		{Body: []byte("one"), WordGoal: 2},
+		{Body: []byte("one two three"), WordGoal: 3},
+		{Body: []byte("no goal")},
+	}
+	if got, want := GoalCompletionRate(entries), 2.0/3; got != want {
+		t.Errorf("GoalCompletionRate = %v, want %v", got, want)
+	}
+	if got := GoalCompletionRate(entries[3:]); got != 0 {
+		t.Errorf("GoalCompletionRate without goals = %v, want 0", got)
+	}
+}
+
+// withBody returns an in-memory Entry for date with body.
+func withBody(date time.Time, body string) *Entry {
+	return &Entry{Path: entryPath("journal", date), Body: []byte(body)}
+}
+
+func TestAverageWordCount(t *testing.T) {
+	feb := time.Date(2020, time.February, 10, 0, 0, 0, 0, time.UTC)
+	entries := []*Entry{
+		withBody(testDate, "one two three four"),
+		withBody(testDate.AddDate(0, 0, 1), ""),
+		withBody(feb, "one two"),
+	}
+	if got, want := AverageWordCount(entries), 2.0; got != want {
+		t.Errorf("AverageWordCount = %v, want %v", got, want)
+	}
+	if got := AverageWordCount(nil); got != 0 {
+		t.Errorf("AverageWordCount(nil) = %v, want 0", got)
+	}
+	want := map[time.Time]float64{
+		time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC):  2,
+		time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC): 2,
+	}
+	if got := AverageWordCountByMonth(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("AverageWordCountByMonth = %v, want %v", got, want)
+	}
+}
+
+func TestRatingCoverage(t *testing.T) {
+	entries := []*Entry{
+		{HighMood: 4, LowMood: 2, AverageMood: 3},
+		{HighMood: 4, LowMood: 2},
+		{},
+		{HighMood: 5, LowMood: 5, AverageMood: 5},
+	}
+	if got := RatingCoverage(entries); got != 0.5 {
+		t.Errorf("RatingCoverage = %v, want 0.5", got)
+	}
+	if got := RatingCoverage(nil); got != 0 {
+		t.Errorf("RatingCoverage(nil) = %v, want 0", got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		moods []uint8
+		want  string
+	}{
+		{"empty", nil, ""},
+		{"rated", []uint8{1, 2, 3, 4, 5}, "▁▂▄▆█"},
+		{"unrated marked", []uint8{5, 0, 1}, "█ ▁"},
+		{"out of range marked", []uint8{6, 3}, " ▄"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sparkline(withAverages(tt.moods...))
+			if got != tt.want {
+				t.Errorf("Sparkline(%v) = %q, want %q", tt.moods, got, tt.want)
+			}
+			if n := len([]rune(got)); n != len(tt.moods) {
+				t.Errorf("Sparkline(%v) has %d characters, want %d", tt.moods, n, len(tt.moods))
+			}
+		})
+	}
+}
+
+func TestMoodDelta(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		prev, curr *Entry
+		want       int
+		ok         bool
+	}{
+		{"rise", &Entry{AverageMood: 2}, &Entry{AverageMood: 5}, 3, true},
+		{"drop", &Entry{AverageMood: 4}, &Entry{AverageMood: 1}, -3, true},
+		{"unchanged", &Entry{AverageMood: 3}, &Entry{AverageMood: 3}, 0, true},
+		{"prev unrated", &Entry{}, &Entry{AverageMood: 3}, 0, false},
+		{"curr unrated", &Entry{AverageMood: 3}, &Entry{}, 0, false},
+		{"nil", nil, &Entry{AverageMood: 3}, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := MoodDelta(tt.prev, tt.curr); got != tt.want || ok != tt.ok {
+				t.Errorf("MoodDelta = %d, %t, want %d, %t", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestHappiestAndLowest(t *testing.T) {
+	entries := withAverages(3, 5, 0, 1, 5, 3)
+	// Reverse the input so that ties can only come out in date order by being sorted.
+	shuffled := make([]*Entry, len(entries))
+	for i, p := range entries {
+		shuffled[len(entries)-1-i] = p
+	}
+	for _, tt := range []struct {
+		name string
+		got  []*Entry
+		want []*Entry
+	}{
+		{"Happiest", Happiest(shuffled, 3), []*Entry{entries[1], entries[4], entries[0]}},
+		{"Lowest", Lowest(shuffled, 2), []*Entry{entries[3], entries[0]}},
+		{"Lowest excludes unrated", Lowest(shuffled, 10), []*Entry{entries[3], entries[0], entries[5], entries[1], entries[4]}},
+		{"zero", Happiest(shuffled, 0), nil},
+	} {
+		if !reflect.DeepEqual(paths(tt.got), paths(tt.want)) {
+			t.Errorf("%s = %v, want %v", tt.name, paths(tt.got), paths(tt.want))
+		}
+	}
+}
+
+func TestTagMoodStats(t *testing.T) {
+	entries := []*Entry{
+		{AverageMood: 2, Tags: []string{"work"}},
+		{AverageMood: 4, Tags: []string{"work", "travel"}},
+		{AverageMood: 5, Tags: []string{"vacation", "travel", "travel"}},
+		{Tags: []string{"work", "sick"}}, // unrated
+		{AverageMood: 3},
+	}
+	want := map[string]MoodStats{
+		"work":     {Count: 2, Mean: 3},
+		"travel":   {Count: 2, Mean: 4.5},
+		"vacation": {Count: 1, Mean: 5},
+	}
+	if got := TagMoodStats(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("TagMoodStats = %v, want %v", got, want)
+	}
+}
+
+func TestWritingHourHistogram(t *testing.T) {
+	at := func(hour, min int) *Entry {
+		return &Entry{ModTime: time.Date(2020, time.January, 2, hour, min, 0, 0, time.UTC)}
+	}
+	entries := []*Entry{at(22, 5), at(22, 59), at(7, 30), at(23, 0), {}}
+
+	var want [24]int
+	want[22], want[7], want[23] = 2, 1, 1
+	if got := WritingHourHistogram(entries, time.UTC); got != want {
+		t.Errorf("WritingHourHistogram in UTC = %v, want %v", got, want)
+	}
+
+	// Five hours behind UTC, the same times fall in earlier hours.
+	want = [24]int{}
+	want[17], want[2], want[18] = 2, 1, 1
+	if got := WritingHourHistogram(entries, time.FixedZone("UTC-5", -5*60*60)); got != want {
+		t.Errorf("WritingHourHistogram in UTC-5 = %v, want %v", got, want)
+	}
+}
+
+// withWords returns in-memory entries on consecutive days from testDate with the given word counts.
+func withWords(counts ...int) []*Entry {
+	entries := make([]*Entry, len(counts))
+	for i, n := range counts {
+		entries[i] = withBody(testDate.AddDate(0, 0, i), strings.Repeat("word ", n))
+	}
+	return entries
+}
+
+func TestWordCountPercentiles(t *testing.T) {
+	entries := withWords(40, 10, 50, 30, 20)
+	got := WordCountPercentiles(entries, 0, 25, 50, 90, 100, -10, 150)
+	want := map[float64]int{0: 10, 25: 20, 50: 30, 90: 46, 100: 50, -10: 10, 150: 50}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordCountPercentiles = %v, want %v", got, want)
+	}
+	// Between two counts the result is interpolated and rounded: rank 0.5 of 10 and 15 is 12.5.
+	if got := WordCountPercentiles(withWords(15, 10), 50); got[50] != 13 {
+		t.Errorf("median of 10 and 15 = %d, want 13", got[50])
+	}
+	if got := WordCountPercentiles(nil, 50); len(got) != 0 {
+		t.Errorf("WordCountPercentiles(nil) = %v, want empty", got)
+	}
+}
+
+// withWordsAndMoods returns in-memory entries on consecutive days from testDate with the given word counts and average moods.
+func withWordsAndMoods(counts []int, moods []uint8) []*Entry {
+	entries := withWords(counts...)
+	for i, p := range entries {
+		p.AverageMood = moods[i]
+	}
+	return entries
+}
+
+func TestWordMoodCorrelation(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		counts []int
+		moods  []uint8
+		want   float64
+	}{
+		{"more words on better days", []int{10, 20, 30}, []uint8{1, 2, 3}, 1},
+		{"more words on worse days", []int{10, 20, 30}, []uint8{5, 3, 1}, -1},
+		{"partial", []int{1, 2, 3, 4}, []uint8{1, 3, 2, 4}, 0.8},
+		{"unrated left out", []int{1, 2, 3, 4, 1000}, []uint8{1, 3, 2, 4, 0}, 0.8},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WordMoodCorrelation(withWordsAndMoods(tt.counts, tt.moods))
+			if err != nil || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("WordMoodCorrelation = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordMoodCorrelationTooFewPoints(t *testing.T) {
+	for name, entries := range map[string][]*Entry{
+		"none":          nil,
+		"one rated":     withWordsAndMoods([]int{1, 2}, []uint8{3, 0}),
+		"constant mood": withWordsAndMoods([]int{1, 2, 3}, []uint8{3, 3, 3}),
+	} {
+		if _, err := WordMoodCorrelation(entries); !errors.Is(err, ErrTooFewPoints) {
+			t.Errorf("WordMoodCorrelation with %s = %v, want ErrTooFewPoints", name, err)
+		}
+	}
+}
+
+func TestWordsPerActiveDay(t *testing.T) {
+	entries := []*Entry{
+		withBody(testDate, "one two three"),
+		// A second entry on the same day adds to it rather than counting as another day.
+		{Path: withSuffix(entryPath("journal", testDate), 2), Body: []byte("four five")},
+		withBody(testDate.AddDate(0, 0, 1), ""),
+		withBody(testDate.AddDate(0, 0, 5), "six seven eight nine ten eleven seven"),
+	}
+	// 12 words over the 2 days with writing, ignoring the empty day and the 3 days without entries.
+	if got := WordsPerActiveDay(entries); got != 6 {
+		t.Errorf("WordsPerActiveDay = %v, want 6", got)
+	}
+	if got := WordsPerActiveDay(entries[2:3]); got != 0 {
+		t.Errorf("WordsPerActiveDay of an empty entry = %v, want 0", got)
+	}
+	if got := WordsPerActiveDay(nil); got != 0 {
+		t.Errorf("WordsPerActiveDay(nil) = %v, want 0", got)
+	}
+}
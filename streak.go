@@ -0,0 +1,176 @@
+package journalentry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNoEntries is returned when a directory has no entries to work with.
+var ErrNoEntries = errors.New("no entries")
+
+// LongestStreak returns the length of the longest run of consecutive days with entries in dir, along with the dates the run starts and ends.
+// If several runs share the longest length, the earliest is returned.
+func LongestStreak(dir string) (length int, start, end time.Time, err error) {
+	return longestStreakFS(OS, dir)
+}
+
+// longestStreakFS is LongestStreak for dir in fsys.
+func longestStreakFS(fsys FS, dir string) (length int, start, end time.Time, err error) {
+	dates, err := entryDatesFS(fsys, dir)
+	if err != nil {
+		return 0, start, end, err
+	}
+	length, start, end = longestRun(dates)
+	return length, start, end, nil
+}
+
+// longestRun returns the length, start, and end of the earliest longest run of consecutive days in dates, which must be sorted and distinct.
+func longestRun(dates []time.Time) (length int, start, end time.Time) {
+	runLength, runStart := 0, time.Time{}
+	for i, date := range dates {
+		if i > 0 && dates[i-1].AddDate(0, 0, 1).Equal(date) {
+			runLength++
+		} else {
+			runLength, runStart = 1, date
+		}
+		if runLength > length {
+			length, start, end = runLength, runStart, date
+		}
+	}
+	return length, start, end
+}
+
+// CurrentGap returns the number of consecutive days up to and including asOf's day without an entry in dir.
+// It is 0 if there is an entry for asOf's day, and ErrNoEntries is returned if there are none up to then.
+func CurrentGap(dir string, asOf time.Time) (int, error) {
+	dates, err := entryDates(dir)
+	if err != nil {
+		return 0, err
+	}
+	today := day(asOf)
+	for i := len(dates) - 1; i >= 0; i-- {
+		if !dates[i].After(today) {
+			return daysBetween(dates[i], today), nil
+		}
+	}
+	return 0, ErrNoEntries
+}
+
+// daysBetween returns the number of calendar days from a to b, both midnight UTC.
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// GapHistogram returns how often each gap, in days, occurs between consecutive entry dates in dir.
+// A gap of 1 means entries on consecutive days; a gap of 3 means two days were skipped.
+func GapHistogram(dir string) (map[int]int, error) {
+	dates, err := entryDates(dir)
+	if err != nil {
+		return nil, err
+	}
+	gaps := make(map[int]int)
+	for i := 1; i < len(dates); i++ {
+		gaps[daysBetween(dates[i-1], dates[i])]++
+	}
+	return gaps, nil
+}
+
+// ConsistencyScore rates the writing habit in dir over the window ending on asOf's day, from 0 to 100.
+// It blends three sub-metrics, each a fraction of the days in the window:
+// the longest streak within the window (40%), the days with an entry (40%),
+// and the share of those entries that are rated, as in RatingCoverage (20%).
+// As with Entries, files that fail to load are left out and their errors returned.
+func ConsistencyScore(dir string, window time.Duration, asOf time.Time) (int, error) {
+	days := int(math.Ceil(window.Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	last := day(asOf)
+	first := last.AddDate(0, 0, 1-days)
+	entries, err := loadWhere(dir, func(date time.Time) bool {
+		return !date.Before(first) && !date.After(last)
+	})
+	dates := distinctDates(entries)
+	streak, _, _ := longestRun(dates)
+	score := 0.4*float64(streak)/float64(days) +
+		0.4*float64(len(dates))/float64(days) +
+		0.2*RatingCoverage(entries)
+	return int(math.Round(100 * score)), err
+}
+
+// Span is a run of consecutive days, from Start to End inclusive.
+type Span struct {
+	Start, End time.Time
+}
+
+// CoveredSpans returns each maximal run of consecutive days with entries in dir, in date order.
+// A day with no entry on either side is a span on its own, starting and ending on that day.
+func CoveredSpans(dir string) ([]Span, error) {
+	dates, err := entryDates(dir)
+	if err != nil {
+		return nil, err
+	}
+	var spans []Span
+	for i, date := range dates {
+		if i > 0 && dates[i-1].AddDate(0, 0, 1).Equal(date) {
+			spans[len(spans)-1].End = date
+			continue
+		}
+		spans = append(spans, Span{Start: date, End: date})
+	}
+	return spans, nil
+}
+
+// Thresholds for the badges returned by Achievements.
+var (
+	streakBadges = []int{7, 30, 100, 365}
+	entryBadges  = []int{10, 30, 100, 365, 1000}
+	wordBadges   = []int{1000, 10000, 100000, 1000000}
+)
+
+// Achievements returns the badges earned by the entries in dir dated up to asOf's day: "N-day streak" for a longest streak
+// of at least 7, 30, 100, or 365 days; "N entries" for at least 10, 30, 100, 365, or 1000 entries; and "N words total"
+// for at least 1000, 10000, 100000, or 1000000 words. Badges are listed in that order, smallest first within each kind.
+// As with Entries, files that fail to load are left out and their errors returned.
+func Achievements(dir string, asOf time.Time) ([]string, error) {
+	last := day(asOf)
+	entries, err := loadWhere(dir, func(date time.Time) bool {
+		return !date.After(last)
+	})
+	words := 0
+	for _, p := range entries {
+		words += p.WordCount()
+	}
+	streak, _, _ := longestRun(distinctDates(entries))
+	var badges []string
+	for _, n := range streakBadges {
+		if streak >= n {
+			badges = append(badges, fmt.Sprintf("%d-day streak", n))
+		}
+	}
+	for _, n := range entryBadges {
+		if len(entries) >= n {
+			badges = append(badges, fmt.Sprintf("%d entries", n))
+		}
+	}
+	for _, n := range wordBadges {
+		if words >= n {
+			badges = append(badges, fmt.Sprintf("%d words total", n))
+		}
+	}
+	return badges, err
+}
+
+// distinctDates returns the dates of entries, which must be sorted by date, without repeats.
+func distinctDates(entries []*Entry) []time.Time {
+	var dates []time.Time
+	for _, p := range entries {
+		date, err := p.Date()
+		if err == nil && (len(dates) == 0 || !date.Equal(dates[len(dates)-1])) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
@@ -0,0 +1,168 @@
+package journalentry
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeEntries writes an empty Entry file in dir for each date.
+func writeEntries(t *testing.T, dir string, dates ...time.Time) {
+	t.Helper()
+	for _, date := range dates {
+		writeEntry(t, dir, date, "")
+	}
+}
+
+// days returns the dates n days after testDate for each n.
+func days(n ...int) []time.Time {
+	dates := make([]time.Time, len(n))
+	for i, d := range n {
+		dates[i] = testDate.AddDate(0, 0, d)
+	}
+	return dates
+}
+
+func TestLongestStreak(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 1, 5, 6, 7, 8, 10)...)
+	length, start, end, err := LongestStreak(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if length != 4 || !start.Equal(testDate.AddDate(0, 0, 5)) || !end.Equal(testDate.AddDate(0, 0, 8)) {
+		t.Errorf("LongestStreak = %d from %v to %v, want 4 from day 5 to day 8", length, start, end)
+	}
+}
+
+func TestLongestStreakTieKeepsEarliest(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 1, 3, 4)...)
+	length, start, _, err := LongestStreak(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if length != 2 || !start.Equal(testDate) {
+		t.Errorf("LongestStreak = %d from %v, want 2 from %v", length, start, testDate)
+	}
+}
+
+func TestLongestStreakEmpty(t *testing.T) {
+	length, _, _, err := LongestStreak(t.TempDir())
+	if err != nil || length != 0 {
+		t.Errorf("LongestStreak of an empty directory = %d, %v", length, err)
+	}
+}
+
+func TestCurrentGap(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := CurrentGap(dir, testDate); err != ErrNoEntries {
+		t.Errorf("CurrentGap with no entries = %v, want ErrNoEntries", err)
+	}
+	writeEntries(t, dir, days(0, 1)...)
+	for _, tt := range []struct {
+		asOf time.Time
+		want int
+	}{
+		{testDate.AddDate(0, 0, 1).Add(20 * time.Hour), 0},
+		{testDate.AddDate(0, 0, 4), 3},
+	} {
+		if got, err := CurrentGap(dir, tt.asOf); err != nil || got != tt.want {
+			t.Errorf("CurrentGap as of %v = %d, %v, want %d", tt.asOf, got, err, tt.want)
+		}
+	}
+	if _, err := CurrentGap(dir, testDate.AddDate(0, 0, -1)); err != ErrNoEntries {
+		t.Errorf("CurrentGap before the first entry = %v, want ErrNoEntries", err)
+	}
+}
+
+func TestGapHistogram(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 1, 2, 5, 6, 9)...)
+	// A second file on one day doesn't count as a gap of 0.
+	writeEntry(t, dir, testDate.AddDate(0, 0, 100), "")
+	if err := os.WriteFile(withSuffix(entryPath(dir, testDate.AddDate(0, 0, 100)), 2), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	gaps, err := GapHistogram(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]int{1: 3, 3: 2, 91: 1}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("GapHistogram = %v, want %v", gaps, want)
+	}
+}
+
+func TestConsistencyScore(t *testing.T) {
+	const rated = "---\nhighmood: 4\nlowmood: 2\naveragemood: 3\n---\n"
+	perfect := t.TempDir()
+	for i := 0; i < 10; i++ {
+		writeEntry(t, perfect, testDate.AddDate(0, 0, i), rated)
+	}
+	asOf := testDate.AddDate(0, 0, 9).Add(15 * time.Hour)
+	if score, err := ConsistencyScore(perfect, 10*24*time.Hour, asOf); err != nil || score != 100 {
+		t.Errorf("ConsistencyScore of a perfect habit = %d, %v, want 100", score, err)
+	}
+
+	sparse := t.TempDir()
+	writeEntry(t, sparse, testDate.AddDate(0, 0, 5), "")
+	// Entries outside the window don't count.
+	writeEntries(t, sparse, days(-3, -2, -1, 40)...)
+	score, err := ConsistencyScore(sparse, 30*24*time.Hour, testDate.AddDate(0, 0, 29))
+	if err != nil || score > 5 {
+		t.Errorf("ConsistencyScore of a sparse habit = %d, %v, want near 0", score, err)
+	}
+}
+
+func TestCoveredSpans(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, days(0, 1, 2, 5, 6, 9)...)
+	// A second file on a day inside a span doesn't split it.
+	if err := os.WriteFile(withSuffix(entryPath(dir, testDate.AddDate(0, 0, 1)), 2), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	spans, err := CoveredSpans(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := days(0, 2, 5, 6, 9)
+	if want := []Span{{d[0], d[1]}, {d[2], d[3]}, {d[4], d[4]}}; !reflect.DeepEqual(spans, want) {
+		t.Errorf("CoveredSpans = %v, want %v", spans, want)
+	}
+}
+
+func TestCoveredSpansEmpty(t *testing.T) {
+	if spans, err := CoveredSpans(t.TempDir()); err != nil || len(spans) != 0 {
+		t.Errorf("CoveredSpans of an empty directory = %v, %v", spans, err)
+	}
+}
+
+func TestAchievements(t *testing.T) {
+	dir := t.TempDir()
+	body := "---\n---\n" + strings.Repeat("word ", 100) + "\n"
+	for _, n := range []int{0, 1, 2, 3, 4, 5, 6, 7, 20, 30, 40, 50} {
+		writeEntry(t, dir, testDate.AddDate(0, 0, n), body)
+	}
+	// A 31-day streak after asOf doesn't count.
+	for n := 60; n <= 90; n++ {
+		writeEntry(t, dir, testDate.AddDate(0, 0, n), body)
+	}
+	for _, tt := range []struct {
+		asOf time.Time
+		want []string
+	}{
+		{testDate.AddDate(0, 0, 55), []string{"7-day streak", "10 entries", "1000 words total"}},
+		{testDate.AddDate(0, 0, 6).Add(12 * time.Hour), []string{"7-day streak"}},
+		{testDate.AddDate(0, 0, -1), nil},
+	} {
+		got, err := Achievements(dir, tt.asOf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Achievements as of %s = %q, want %q", tt.asOf.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
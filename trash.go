@@ -0,0 +1,45 @@
+package journalentry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// trashStamp is the layout of the time prefixed to the names of trashed files.
+const trashStamp = "20060102T150405.000000000"
+
+// Trash moves p's file, and its sidecar file if any, into trashDir, creating it if needed, and updates p.Path.
+// The file's name is prefixed with the current time, so entries trashed more than once don't collide.
+// Restore moves it back. Like os.Rename, it fails if trashDir is on a different filesystem.
+func (p *Entry) Trash(trashDir string) error {
+	if err := os.MkdirAll(trashDir, 0777); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(trashStamp) + "-" + filepath.Base(p.Path)
+	trashPath := filepath.Join(trashDir, name)
+	if err := moveEntry(OS, p.Path, trashPath); err != nil {
+		return err
+	}
+	p.Path = trashPath
+	return nil
+}
+
+// Restore moves a file trashed by Trash, and its sidecar file if any, back into dir under its original name and returns its new path.
+// If an entry has since been created at that name, the restored file gets the first free suffixed name instead.
+func Restore(trashPath, dir string) (string, error) {
+	stamp, name, ok := strings.Cut(filepath.Base(trashPath), "-")
+	if _, err := time.Parse(trashStamp, stamp); !ok || err != nil {
+		return "", fmt.Errorf("%s: not a trashed entry", trashPath)
+	}
+	path, err := freePath(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	if err := moveEntry(OS, trashPath, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
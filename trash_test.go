@@ -0,0 +1,81 @@
+package journalentry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	trashDir := filepath.Join(t.TempDir(), "trash")
+	path := writeEntry(t, dir, testDate, "---\n---\nbody\n")
+	p := &Entry{Path: path}
+	if err := p.Trash(trashDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after Trash: %v", path, err)
+	}
+	if filepath.Dir(p.Path) != trashDir || !strings.HasSuffix(p.Path, "-"+filepath.Base(path)) {
+		t.Errorf("Path after Trash = %s, want a timestamped name in %s", p.Path, trashDir)
+	}
+	if got := readString(t, p.Path); got != "---\n---\nbody\n" {
+		t.Errorf("trashed file = %q", got)
+	}
+
+	restored, err := Restore(p.Path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != path {
+		t.Errorf("Restore = %s, want %s", restored, path)
+	}
+	if got := readString(t, path); got != "---\n---\nbody\n" {
+		t.Errorf("restored file = %q", got)
+	}
+	if entries, _ := os.ReadDir(trashDir); len(entries) != 0 {
+		t.Errorf("trash has %d files after Restore, want none", len(entries))
+	}
+}
+
+func TestTrashTwice(t *testing.T) {
+	dir, trashDir := t.TempDir(), t.TempDir()
+	for i := 0; i < 2; i++ {
+		p := &Entry{Path: writeEntry(t, dir, testDate, "")}
+		if err := p.Trash(trashDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, _ := os.ReadDir(trashDir); len(entries) != 2 {
+		t.Errorf("trash has %d files after trashing the same name twice, want 2", len(entries))
+	}
+}
+
+func TestRestoreTaken(t *testing.T) {
+	dir, trashDir := t.TempDir(), t.TempDir()
+	path := writeEntry(t, dir, testDate, "old\n")
+	p := &Entry{Path: path}
+	if err := p.Trash(trashDir); err != nil {
+		t.Fatal(err)
+	}
+	writeEntry(t, dir, testDate, "new\n")
+	restored, err := Restore(p.Path, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := withSuffix(path, 2); restored != want {
+		t.Errorf("Restore over an existing entry = %s, want %s", restored, want)
+	}
+	if got := readString(t, path); got != "new\n" {
+		t.Errorf("existing entry = %q after Restore, want it untouched", got)
+	}
+}
+
+func TestRestoreNotTrashed(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Restore(writeEntry(t, dir, testDate, ""), dir); err == nil {
+		t.Error("Restore of a file that wasn't trashed succeeded")
+	}
+}